@@ -17,6 +17,7 @@ const (
 	RLActionStrafeRight
 	RLActionTurnLeft
 	RLActionTurnRight
+	RLActionUse
 )
 
 type RLActionResult struct {
@@ -51,14 +52,14 @@ func clientPlayerMessageLoop(cc *ipc.Client) {
 			break
 		}
 
-		if m.MsgType == -1 { // message type -1 is status change
+		if m.MsgType == ipc.MsgStatusChange {
 			log.Println("Status: " + m.Status)
 			if m.Status == "Connected" {
-				cc.Write(100, []byte("begin control"))
+				cc.Write(ipc.MsgBeginControl, []byte("begin control"))
 			}
 		}
 
-		if m.MsgType == -2 { // message type -2 is an error, these won't automatically cause the recieve channel to close.
+		if m.MsgType == ipc.MsgConnectionError { // these won't automatically cause the recieve channel to close.
 			log.Println("Error: " + err.Error())
 		}
 
@@ -75,7 +76,7 @@ func handleClientPlayerMessage(cc *ipc.Client, m *ipc.Message) {
 	log.Println(" Message type: ", m.MsgType)
 	log.Println("Client recieved: " + string(m.Data))
 
-	if m.MsgType == 101 && string(m.Data) == "control granted" {
+	if m.MsgType == ipc.MsgControlGranted && string(m.Data) == "control granted" {
 
 		go sendRandomCommands(cc)
 	}
@@ -88,7 +89,7 @@ func sendRandomCommands(cc *ipc.Client) {
 
 		// choose random action from RLAction enum
 		randomAction := RLAction(1 + rand.Intn(6))
-		_ = cc.Write(200, []byte{byte(randomAction)})
+		_ = cc.Write(ipc.MsgStep, []byte{byte(randomAction)})
 		time.Sleep(time.Second / 16)
 	}
 }
@@ -96,7 +97,7 @@ func sendRandomCommands(cc *ipc.Client) {
 func clientPlayerWaitLoop(cc *ipc.Client) {
 
 	for {
-		_ = cc.Write(666, []byte("ping"))
+		_ = cc.Write(ipc.MsgPing, []byte("ping"))
 
 		time.Sleep(time.Second * 15)
 
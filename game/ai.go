@@ -1,9 +1,8 @@
 package game
 
 import (
-	"bytes"
 	"encoding/json"
-	"image/jpeg"
+	"image"
 	"log"
 	"math"
 )
@@ -18,6 +17,7 @@ const (
 	RLActionStrafeRight
 	RLActionTurnLeft
 	RLActionTurnRight
+	RLActionUse // opens the door in front, or closes it when it is open
 )
 
 type RLActionResult struct {
@@ -25,7 +25,22 @@ type RLActionResult struct {
 	Observation     []uint8
 	Observation_Pos []float64
 	Done            bool
+	Truncated       bool // the episode was cut off by the time limit rather than ending
 	Info            string
+
+	// Describes how Observation is encoded
+	Encoding ObservationEncoding
+	Width    int
+	Height   int
+	Channels int
+}
+
+func (r *RLActionResult) setObservation(obs EncodedObservation) {
+	r.Observation = obs.Data
+	r.Encoding = obs.Encoding
+	r.Width = obs.Width
+	r.Height = obs.Height
+	r.Channels = obs.Channels
 }
 
 func (r *RLActionResult) ToJson() *string {
@@ -40,10 +55,12 @@ func (r *RLActionResult) ToJson() *string {
 	}
 }
 
-const maxEpisodeLength = 15 * 60 * 1000
+// Length of an episode before it is cut off, in simulated seconds
+const maxEpisodeSeconds = 15 * 60
 
 func (g *GameInstance) TakePlayer1Action(action_id RLAction) RLActionResult {
 	var reward float32 = 0
+	from := g.player1Controller.player.getPosition()
 	if action_id == RLActionNone {
 		//g.player1Controller.deaccelerateVelocity()
 		//g.player1Controller.deaccelerateHorizontalVelocity()
@@ -72,14 +89,22 @@ func (g *GameInstance) TakePlayer1Action(action_id RLAction) RLActionResult {
 		//g.player1Controller.deaccelerateVelocity()
 		//g.player1Controller.deaccelerateHorizontalVelocity()
 		g.player1Controller.turnRight(0.1)
+	} else if action_id == RLActionUse {
+		g.useDoor(g.player1Controller.player.view)
 	} else {
-		log.Fatal("Unknown action type ", action_id)
+		// the step is skipped, the caller gets the unchanged observation back
+		result := g.ObservationResult()
+		result.Info = "unknown action"
+		return result
 	}
+	g.autopilot().observe(action_id, from)
+
+	g.advanceClock()
 
 	// update and save the player's position to p.player.view.old_position every 1000 frames
 	distTravelled := math.Sqrt(math.Pow(g.player1Controller.player.game.player1Controller.player.old_position.X-g.player1Controller.player.game.player1Controller.player.view.position.X, 2) + math.Pow(g.player1Controller.player.game.player1Controller.player.old_position.Y-g.player1Controller.player.game.player1Controller.player.view.position.Y, 2))
-	if g.player1Controller.player.game.currentTick-g.player1Controller.player.game.episodeStartTick > 100 {
-		if g.player1Controller.player.game.currentTick-g.player1Controller.player.game.lastPlayer1PositionUpdateTick > (3 * 1000) {
+	if g.player1Controller.player.game.currentTick-g.player1Controller.player.game.episodeStartTick > g.secondsToTicks(0.1) {
+		if g.player1Controller.player.game.currentTick-g.player1Controller.player.game.lastPlayer1PositionUpdateTick > g.secondsToTicks(3) {
 
 			//print("distTravelled: ", distTravelled, "\r\n")
 
@@ -103,7 +128,7 @@ func (g *GameInstance) TakePlayer1Action(action_id RLAction) RLActionResult {
 
 	reward = g.player1Controller.player.getReward()
 
-	p1Obs, p10img := g.GetPlayer1Observation()
+	p1Obs, p1Img := g.GetPlayer1Observation()
 
 	episodeLength := g.currentTick - g.episodeStartTick
 
@@ -111,12 +136,14 @@ func (g *GameInstance) TakePlayer1Action(action_id RLAction) RLActionResult {
 
 	//touchingWall := g.player1Controller.player.view.distanceToWall < 0.5 //|| g.distToNearestWall(g.player1Controller.player.view.position, 0.5) < 1.5
 
-	done := g.player1Controller.player.isDone() || episodeLength > maxEpisodeLength || isNotMoving
+	truncated := episodeLength > g.secondsToTicks(maxEpisodeSeconds)
+
+	done := g.player1Controller.player.isDone() || truncated || isNotMoving
 
 	if g.player1Controller.player.isDone() {
 		print("Player is done", "\r\n")
 	}
-	if episodeLength > maxEpisodeLength {
+	if truncated {
 		print("Episode length exceeded", "\r\n")
 	}
 
@@ -124,12 +151,36 @@ func (g *GameInstance) TakePlayer1Action(action_id RLAction) RLActionResult {
 		reward = -2
 	}
 
-	return RLActionResult{Reward: reward, Observation: p10img, Observation_Pos: p1Obs, Done: done, Info: ""}
+	result := RLActionResult{Reward: reward, Observation_Pos: p1Obs, Done: done, Truncated: truncated, Info: ""}
+	result.setObservation(p1Img)
+
+	g.recordStep(action_id, result)
+	g.logEpisodeStep(action_id, result)
+
+	return result
+}
+
+// ObservationResult returns the current observation of player 1 without stepping the game
+func (g *GameInstance) ObservationResult() RLActionResult {
+	p1Obs, p1Img := g.GetPlayer1Observation()
+
+	result := RLActionResult{Reward: 0.0, Observation_Pos: p1Obs, Done: false, Info: "dummy"}
+	result.setObservation(p1Img)
+	return result
 }
 
-func (g *GameInstance) GetPlayer1Observation() ([]float64, []byte) {
+func (g *GameInstance) GetPlayer1Observation() ([]float64, EncodedObservation) {
 	values := g.player1Controller.player.getIntensityValuesAroundPlayer()
+	return flattenIntensity(values), g.encodeRenderBuffer(g.renderListener, g.ObservationFormat())
+}
+
+// GetPlayer2Observation returns the chaser's intensity values and its last rendered view
+func (g *GameInstance) GetPlayer2Observation() ([]float64, EncodedObservation) {
+	values := g.player2Controller.player.getIntensityValuesAroundPlayer()
+	return flattenIntensity(values), g.encodeRenderBuffer(g.renderListener2, g.ChaserObservationFormat())
+}
 
+func flattenIntensity(values [][]float64) []float64 {
 	// Flatten the 2d array of values
 	flatValues := make([]float64, len(values)*len(values[0]))
 	for i := 0; i < len(values); i++ {
@@ -146,26 +197,28 @@ func (g *GameInstance) GetPlayer1Observation() ([]float64, []byte) {
 			}
 		}
 	}
+	return flatValues
+}
 
-	// Convert g.renderListener.renderBuffer into grayscale
-
+func (g *GameInstance) encodeRenderBuffer(listener *RenderListener, f ObservationFormat) EncodedObservation {
 	// lock and synchronise the renderBuffer
-	g.renderListener.renderBufferMutex.Lock()
-	defer g.renderListener.renderBufferMutex.Unlock()
-	img := g.renderListener.renderBuffer
+	listener.renderBufferMutex.Lock()
+	defer listener.renderBufferMutex.Unlock()
+	img := listener.renderBuffer
 	if img == nil {
-		return flatValues, nil
+		return EncodedObservation{}
 	}
 
-	// Compress the renderBuffer into JPEG
-	var buf bytes.Buffer
-	err := jpeg.Encode(&buf, g.renderListener.renderBuffer, &jpeg.Options{Quality: 60})
-	if err != nil {
-		log.Println("Error compressing observation: ", err)
-		return nil, nil
-	} else {
-
-		return flatValues, buf.Bytes()
+	var topDown *image.RGBA
+	if f.TopDown {
+		topDown = g.topDownObservation(img.Bounds().Dx(), img.Bounds().Dy())
+	}
 
+	// Encode the renderBuffer in the negotiated format
+	obs, err := encodeObservation(img, topDown, f)
+	if err != nil {
+		log.Println("Error encoding observation: ", err)
+		return EncodedObservation{}
 	}
+	return obs
 }
@@ -0,0 +1,86 @@
+package game
+
+// BSPGenerator - splits the map into nested halves until they reach MinLeafSize, puts a room into every leaf and
+// joins the two halves of every split with a corridor, so all rooms are connected along the tree
+type BSPGenerator struct {
+	MinLeafSize   int // a part is only split when both halves keep at least this many cells along the split
+	CorridorWidth int // width of the corridors between the rooms, in cells
+}
+
+// DefaultBSPGenerator is used for every parameter left at zero
+var DefaultBSPGenerator = BSPGenerator{MinLeafSize: 10, CorridorWidth: 1}
+
+func (b BSPGenerator) withDefaults() BSPGenerator {
+	if b.MinLeafSize == 0 {
+		b.MinLeafSize = DefaultBSPGenerator.MinLeafSize
+	}
+	if b.CorridorWidth == 0 {
+		b.CorridorWidth = DefaultBSPGenerator.CorridorWidth
+	}
+	return b
+}
+
+// Generate partitions the map and returns the rooms and corridors, solid rock is wall (2), the walls of the
+// rooms are room walls (4) and the openings where corridors enter a room are recorded as doors
+func (b BSPGenerator) Generate(rows int, cols int, seed int64) *Map {
+	b = b.withDefaults()
+
+	m := newRockMap(rows, cols, seed)
+
+	// the partition covers the cells inside the boundary, every leaf keeps a ring of rock for the room walls
+	rooms := m.splitLeaf(b, room{x: 1, y: 1, w: rows - 2, h: cols - 2})
+
+	cx, cy := rooms[0].center()
+	m.sealUnreachable(cx, cy)
+
+	return m
+}
+
+// splitLeaf partitions a part of the map and returns the rooms placed into it, the rooms of the two halves
+// are joined by a corridor between a room of each half
+func (m *Map) splitLeaf(b BSPGenerator, leaf room) []room {
+	// split across the longer side, a part that can't be halved any more becomes a room
+	horizontal := leaf.w > leaf.h || (leaf.w == leaf.h && m.rng.Intn(2) == 0)
+	size := leaf.h
+	if horizontal {
+		size = leaf.w
+	}
+	if size < 2*b.MinLeafSize {
+		return []room{m.leafRoom(b, leaf)}
+	}
+
+	at := b.MinLeafSize + m.rng.Intn(size-2*b.MinLeafSize+1)
+	first, second := leaf, leaf
+	if horizontal {
+		first.w = at
+		second.x, second.w = leaf.x+at, leaf.w-at
+	} else {
+		first.h = at
+		second.y, second.h = leaf.y+at, leaf.h-at
+	}
+
+	a := m.splitLeaf(b, first)
+	c := m.splitLeaf(b, second)
+	m.carveCorridor(a[m.rng.Intn(len(a))], c[m.rng.Intn(len(c))], b.CorridorWidth)
+
+	return append(a, c...)
+}
+
+// leafRoom places a room of random size into a leaf, keeping its walls inside the leaf
+func (m *Map) leafRoom(b BSPGenerator, leaf room) room {
+	maxW, maxH := leaf.w-2, leaf.h-2
+	if maxW < 1 {
+		maxW = 1
+	}
+	if maxH < 1 {
+		maxH = 1
+	}
+
+	// rooms fill at least half of their leaf
+	w := (maxW+1)/2 + m.rng.Intn(maxW-(maxW+1)/2+1)
+	h := (maxH+1)/2 + m.rng.Intn(maxH-(maxH+1)/2+1)
+	r := room{x: leaf.x + 1 + m.rng.Intn(maxW-w+1), y: leaf.y + 1 + m.rng.Intn(maxH-h+1), w: w, h: h}
+
+	m.addRoom(r)
+	return r
+}
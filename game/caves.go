@@ -0,0 +1,68 @@
+package game
+
+// CaveGenerator - caves grown by a cellular automaton: cells start as wall at random, then every iteration
+// a cell becomes wall when most of its neighbours are wall. Only the largest cave is kept.
+type CaveGenerator struct {
+	WallChance float64 // chance of a cell starting as wall
+	Iterations int     // smoothing iterations of the automaton
+}
+
+// DefaultCaveGenerator is used for every parameter left at zero
+var DefaultCaveGenerator = CaveGenerator{WallChance: 0.45, Iterations: 5}
+
+func (c CaveGenerator) withDefaults() CaveGenerator {
+	if c.WallChance == 0 {
+		c.WallChance = DefaultCaveGenerator.WallChance
+	}
+	if c.Iterations == 0 {
+		c.Iterations = DefaultCaveGenerator.Iterations
+	}
+	return c
+}
+
+// Generate grows the caves inside solid wall (2), cut off caves are filled in
+func (c CaveGenerator) Generate(rows int, cols int, seed int64) *Map {
+	c = c.withDefaults()
+
+	m := newRockMap(rows, cols, seed)
+
+	for i := 1; i < rows-1; i++ {
+		for j := 1; j < cols-1; j++ {
+			if m.rng.Float64() >= c.WallChance {
+				m.mapData[i][j] = 0
+			}
+		}
+	}
+
+	for n := 0; n < c.Iterations; n++ {
+		next := make([][]int, rows)
+		for i := range m.mapData {
+			next[i] = append([]int(nil), m.mapData[i]...)
+		}
+
+		for i := 1; i < rows-1; i++ {
+			for j := 1; j < cols-1; j++ {
+				walls := 0
+				for x := i - 1; x <= i+1; x++ {
+					for y := j - 1; y <= j+1; y++ {
+						if (x != i || y != j) && m.mapData[x][y] != 0 {
+							walls++
+						}
+					}
+				}
+
+				if walls >= 5 {
+					next[i][j] = 2
+				} else if walls <= 3 {
+					next[i][j] = 0
+				}
+			}
+		}
+		m.mapData = next
+	}
+
+	m.keepLargestRegion()
+	m.scatterLights(40, 5)
+
+	return m
+}
@@ -0,0 +1,175 @@
+package game
+
+import (
+	"errors"
+
+	"gameenv_ai/pathfinding"
+
+	"github.com/faiface/pixel"
+)
+
+// ChaserBehaviour - built-in policy that drives the chaser when no remote agent controls it
+type ChaserBehaviour string
+
+const (
+	ChaserIdle         ChaserBehaviour = "idle"          // stands still
+	ChaserRandomWalk   ChaserBehaviour = "random_walk"   // wanders, turning away from walls
+	ChaserPatrol       ChaserBehaviour = "patrol"        // walks from room door to room door
+	ChaserLineOfSight  ChaserBehaviour = "line_of_sight" // heads for the runner while it can see it, otherwise wanders
+	ChaserShortestPath ChaserBehaviour = "shortest_path" // follows the shortest path to the runner
+)
+
+// DefaultChaserBehaviour is used when neither the instance nor the reset config pick a behaviour
+const DefaultChaserBehaviour = ChaserIdle
+
+// Validate returns an error when the behaviour is not one of the built-in behaviours
+func (b ChaserBehaviour) Validate() error {
+	switch b {
+	case ChaserIdle, ChaserRandomWalk, ChaserPatrol, ChaserLineOfSight, ChaserShortestPath:
+		return nil
+	}
+	return errors.New("unknown chaser behaviour: " + string(b))
+}
+
+// chaserBehaviour returns the behaviour configured on the instance
+func (g *GameInstance) chaserBehaviour() ChaserBehaviour {
+	if g.ChaserBehaviour == "" {
+		return DefaultChaserBehaviour
+	}
+	return g.ChaserBehaviour
+}
+
+// resetBehaviour selects the behaviour for a new episode and forgets the previous episode's plan.
+// An empty behaviour follows the instance's behaviour.
+func (c *EnemyController) resetBehaviour(behaviour ChaserBehaviour) {
+	c.behaviour = behaviour
+	c.path = nil
+	c.patrolIndex = 0
+	c.hasLastSeen = false
+	c.walkTurn = RLActionNone
+}
+
+// act runs one step of the scripted behaviour, called every time the simulation clock advances
+func (c *EnemyController) act() {
+	c.applyAction(c.player.game.throughDoors(c.player.view, c.nextAction()))
+}
+
+func (c *EnemyController) nextAction() RLAction {
+	behaviour := c.behaviour
+	if behaviour == "" {
+		behaviour = c.player.game.chaserBehaviour()
+	}
+
+	switch behaviour {
+	case ChaserRandomWalk:
+		return c.randomWalk()
+	case ChaserPatrol:
+		return c.patrol()
+	case ChaserLineOfSight:
+		return c.lineOfSightPursuit()
+	case ChaserShortestPath:
+		return c.shortestPathPursuit()
+	}
+	return RLActionNone
+}
+
+// randomWalk keeps moving forward, and every now and then or when a wall is ahead picks a new heading
+func (c *EnemyController) randomWalk() RLAction {
+	g := c.player.game
+
+	if c.walkTurn != RLActionNone {
+		if g.rng.Intn(4) == 0 {
+			c.walkTurn = RLActionNone
+		}
+		return c.walkTurn
+	}
+
+	ahead := c.player.view.position.Add(c.player.view.direction.Scaled(0.6))
+	if (!isWalkable(g.mapData, ahead) && g.doorAt(int(ahead.X), int(ahead.Y)) == nil) || g.rng.Intn(20) == 0 {
+		c.walkTurn = RLActionTurnLeft
+		if g.rng.Intn(2) == 0 {
+			c.walkTurn = RLActionTurnRight
+		}
+		return c.walkTurn
+	}
+
+	return RLActionMoveForward
+}
+
+// patrol walks the shortest path to each room door in turn
+func (c *EnemyController) patrol() RLAction {
+	g := c.player.game
+	if len(g.doors) == 0 {
+		return c.randomWalk()
+	}
+
+	if len(c.path) == 0 {
+		door := g.doors[c.patrolIndex%len(g.doors)]
+		c.patrolIndex++
+		doorCell := pathfinding.Cell{X: door[0], Y: door[1]}
+		c.path, _ = g.findPath(c.player.getPosition(), doorCell.Center())
+		if len(c.path) == 0 {
+			return RLActionNone
+		}
+	}
+
+	return c.followPath()
+}
+
+// lineOfSightPursuit heads straight for the runner while it is in sight, then for the place it was
+// last seen, and wanders when it has no idea where the runner is
+func (c *EnemyController) lineOfSightPursuit() RLAction {
+	g := c.player.game
+	runner := g.player1Controller.player.getPosition()
+
+	if g.hasLineOfSight(c.player.getPosition(), runner) {
+		c.lastSeen = runner
+		c.hasLastSeen = true
+	}
+
+	if !c.hasLastSeen {
+		return c.randomWalk()
+	}
+
+	if c.player.getPosition().Sub(c.lastSeen).Len() < waypointRadius {
+		c.hasLastSeen = false
+		return c.randomWalk()
+	}
+
+	return c.steerTowards(c.lastSeen)
+}
+
+// shortestPathPursuit re-plans the shortest path to the runner every step
+func (c *EnemyController) shortestPathPursuit() RLAction {
+	g := c.player.game
+
+	path, found := g.findPath(c.player.getPosition(), g.player1Controller.player.getPosition())
+	if !found {
+		return RLActionNone
+	}
+	c.path = path
+
+	return c.followPath()
+}
+
+// followPath steers towards the first waypoint of the current path, dropping waypoints once they are reached
+func (c *EnemyController) followPath() RLAction {
+	c.path = dropReachedWaypoints(c.path, c.player.getPosition())
+	if len(c.path) == 0 {
+		return RLActionNone
+	}
+
+	return c.steerTowards(c.path[0])
+}
+
+func (c *EnemyController) steerTowards(target pixel.Vec) RLAction {
+	return steerTowards(c.player.getPosition(), c.player.getRotation(), target)
+}
+
+func isWalkable(mapData [][]int, p pixel.Vec) bool {
+	x, y := int(p.X), int(p.Y)
+	if p.X < 0 || p.Y < 0 || x >= len(mapData) || y >= len(mapData[x]) {
+		return false
+	}
+	return mapData[x][y] == 0
+}
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestChaserBehavioursAreDeterministic(t *testing.T) {
+	seed := int64(7)
+	for _, behaviour := range []ChaserBehaviour{ChaserIdle, ChaserRandomWalk, ChaserPatrol, ChaserLineOfSight, ChaserShortestPath} {
+		a := newTestGame(t, 64, 48)
+		b := newTestGame(t, 64, 48)
+		cfg := ResetConfig{Seed: &seed, ChaserBehaviour: behaviour, Difficulty: &Difficulty{Doors: true}}
+		a.ResetWithConfig(cfg)
+		b.ResetWithConfig(cfg)
+
+		start := poseOf(a.player2Controller.player.view)
+		moved := false
+		for i := 0; i < 40; i++ {
+			a.TakePlayer1Action(RLActionTurnLeft)
+			b.TakePlayer1Action(RLActionTurnLeft)
+			pa, pb := poseOf(a.player2Controller.player.view), poseOf(b.player2Controller.player.view)
+			if pa != pb {
+				t.Fatalf("%s: step %d chaser at %+v and %+v from the same seed", behaviour, i, pa, pb)
+			}
+			moved = moved || pa != start
+		}
+		if moved != (behaviour != ChaserIdle) {
+			t.Fatalf("%s: chaser moved %v", behaviour, moved)
+		}
+	}
+}
+
+func TestShortestPathClosesDistance(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		g := newTestGame(t, 64, 48)
+		g.ResetWithConfig(ResetConfig{Seed: &seed, ChaserBehaviour: ChaserShortestPath})
+		distance := func() int {
+			path, found := g.findPath(g.player2Controller.player.getPosition(), g.player1Controller.player.getPosition())
+			if !found {
+				t.Fatalf("seed %d: runner out of the chaser's reach", seed)
+			}
+			return len(path)
+		}
+
+		before := distance()
+		for i := 0; i < 30; i++ {
+			g.TakePlayer1Action(RLActionNone)
+		}
+		if after := distance(); after >= before && after > 1 {
+			t.Fatalf("seed %d: path to the runner went from %d to %d waypoints", seed, before, after)
+		}
+	}
+}
+
+func TestPatrolOpensDoors(t *testing.T) {
+	g := doorGame(t)
+	chaser := g.player2Controller
+	chaser.resetBehaviour(ChaserPatrol)
+
+	// the patrol leads from the far side through the door at 2,3 to the cell 2,1 behind it
+	g.doors = [][]int{{2, 3}, {2, 1}}
+	g.player1Controller.player.view.position = pixel.V(1.5, 1.5)
+	for i := 0; ; i++ {
+		if i > 200 {
+			t.Fatalf("patrol never got behind the door, at %v", chaser.player.getPosition())
+		}
+		chaser.act()
+		g.updateDoors(g.ticksPerStep())
+		if p := chaser.player.getPosition(); int(p.X) == 2 && int(p.Y) == 1 {
+			break
+		}
+	}
+	if d := g.doorAt(2, 3); !d.opening {
+		t.Fatal("the chaser walked through a door it didn't open")
+	}
+}
+
+func TestLineOfSightPursuit(t *testing.T) {
+	g := doorGame(t)
+	g.rng = rand.New(rand.NewSource(1))
+	chaser := g.player2Controller
+	chaser.resetBehaviour(ChaserLineOfSight)
+	chaser.player.view.position = pixel.V(2.5, 5.5)
+
+	// the closed door hides the runner
+	chaser.act()
+	if chaser.hasLastSeen {
+		t.Fatal("saw the runner through a closed door")
+	}
+
+	// once it is open, the chaser heads for where it saw the runner, even after the runner left
+	runner := g.player1Controller.player.view
+	runner.position = pixel.V(2.5, 2.5)
+	g.useDoor(runner)
+	g.updateDoors(g.secondsToTicks(doorSlideSeconds))
+	runner.position = pixel.V(2.5, 1.5)
+	chaser.act()
+	if !chaser.hasLastSeen || chaser.lastSeen != runner.position {
+		t.Fatalf("didn't see the runner at %v through the open door", runner.position)
+	}
+
+	// the runner steps out of sight, through the doorway the chaser spots it again
+	runner.position = pixel.V(1.5, 1.5)
+	target := chaser.lastSeen
+	for i := 0; chaser.hasLastSeen; i++ {
+		if i > 100 {
+			t.Fatalf("never reached where the runner was seen, at %v", chaser.player.getPosition())
+		}
+		target = chaser.lastSeen
+		chaser.act()
+		g.updateDoors(g.ticksPerStep())
+	}
+	if target != runner.position {
+		t.Fatalf("lost the runner, last seen at %v", target)
+	}
+	if p := chaser.player.getPosition(); p.Sub(target).Len() >= waypointRadius {
+		t.Fatalf("gave up the pursuit at %v", p)
+	}
+}
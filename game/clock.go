@@ -0,0 +1,46 @@
+package game
+
+import "math"
+
+// The simulation clock only advances when the game is stepped, so episode length and
+// timeouts are measured in simulated ticks rather than wall time.
+
+const defaultTicksPerStep = 1
+const defaultSecondsPerTick = 1.0 / 60
+
+func (g *GameInstance) ticksPerStep() int64 {
+	if g.TicksPerStep <= 0 {
+		return defaultTicksPerStep
+	}
+	return g.TicksPerStep
+}
+
+func (g *GameInstance) secondsPerTick() float64 {
+	if g.SecondsPerTick <= 0 {
+		return defaultSecondsPerTick
+	}
+	return g.SecondsPerTick
+}
+
+// secondsToTicks converts a duration in simulated seconds into a number of ticks
+func (g *GameInstance) secondsToTicks(seconds float64) int64 {
+	return int64(math.Ceil(seconds / g.secondsPerTick()))
+}
+
+// advanceClock moves the simulation forward by one step. The chaser's scripted behaviour
+// acts once per step, unless a remote agent drives it.
+func (g *GameInstance) advanceClock() {
+	g.currentTick += g.ticksPerStep()
+	g.episodeSteps++
+	g.trail = append(g.trail, g.player1Controller.player.view.position)
+	g.updateDoors(g.ticksPerStep())
+
+	if !g.chaserAttached {
+		g.player2Controller.act()
+	}
+}
+
+// CurrentTick returns the simulated tick counter
+func (g *GameInstance) CurrentTick() int64 {
+	return g.currentTick
+}
@@ -0,0 +1,179 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"gameenv_ai/pathfinding"
+
+	"github.com/faiface/pixel"
+)
+
+// Difficulty - curriculum parameters of an episode, set with each reset. Parameters left at zero keep the defaults.
+type Difficulty struct {
+	MapSize          int      `json:",omitempty"` // rows and columns of generated maps, default 48
+	Rooms            int      `json:",omitempty"` // rooms of the scatter and dungeon generators
+	MazeComplexity   *float64 `json:",omitempty"` // 1 (default) is a perfect maze, lower values open loops, 0 opens every inner wall
+	MinSpawnDistance float64  `json:",omitempty"` // shortest path length between the runner and chaser spawns, in cells
+	MaxSpawnDistance float64  `json:",omitempty"` // longest path length between the spawns, 0 has no limit
+	Doors            bool     `json:",omitempty"` // hangs closed doors in the doorways of the rooms
+}
+
+const (
+	DefaultMapSize = 48
+	minMapSize     = 10
+	maxMapSize     = 256
+)
+
+// spawnAttempts bounds the runner spawns tried to find a chaser spawn at the requested distance
+const spawnAttempts = 10
+
+// Validate returns an error when the difficulty can't be applied
+func (d Difficulty) Validate() error {
+	if d.MapSize != 0 && (d.MapSize < minMapSize || d.MapSize > maxMapSize) {
+		return fmt.Errorf("map size must be between %d and %d, got %d", minMapSize, maxMapSize, d.MapSize)
+	}
+	if d.Rooms < 0 {
+		return errors.New("rooms can't be negative")
+	}
+	if d.MazeComplexity != nil && (*d.MazeComplexity < 0 || *d.MazeComplexity > 1) {
+		return fmt.Errorf("maze complexity must be between 0 and 1, got %v", *d.MazeComplexity)
+	}
+	if d.MinSpawnDistance < 0 || d.MaxSpawnDistance < 0 {
+		return errors.New("spawn distances can't be negative")
+	}
+	if d.MaxSpawnDistance != 0 && d.MaxSpawnDistance < d.MinSpawnDistance {
+		return fmt.Errorf("invalid spawn distance range %v-%v", d.MinSpawnDistance, d.MaxSpawnDistance)
+	}
+	return nil
+}
+
+func (d Difficulty) mapSize() int {
+	if d.MapSize == 0 {
+		return DefaultMapSize
+	}
+	return d.MapSize
+}
+
+// Apply returns the generator with the difficulty's parameters, generators without such parameters are unchanged
+func (d Difficulty) Apply(generator MapGenerator) MapGenerator {
+	switch gen := generator.(type) {
+	case ScatterGenerator:
+		if d.Rooms != 0 {
+			gen.Rooms = d.Rooms
+		}
+		return gen
+	case DungeonGenerator:
+		if d.Rooms != 0 {
+			gen.Rooms = d.Rooms
+		}
+		return gen
+	case MazeGenerator:
+		if d.MazeComplexity != nil {
+			gen.Loops = 1 - *d.MazeComplexity
+		}
+		return gen
+	}
+	return generator
+}
+
+// spawnPlayers returns the runner and chaser spawns, the fixed spawns of a loaded map or random ones. When the
+// difficulty limits the spawn distance, the chaser spawns where the shortest path to the runner is within the
+// limits; if no pair of spawns within the limits is found, the pair that comes closest is used.
+func spawnPlayers(m *Map, rng *rand.Rand, d Difficulty) (runner pixel.Vec, chaser pixel.Vec) {
+	// random spawns are limited to the largest floor area, players in separate areas could never meet
+	region := largestRegion(m.mapData)
+
+	runner = spawnPosition(m.runnerSpawn, &m.mapData, rng, region)
+	if m.chaserSpawn != nil || (d.MinSpawnDistance == 0 && d.MaxSpawnDistance == 0) {
+		return runner, spawnPosition(m.chaserSpawn, &m.mapData, rng, region, runner)
+	}
+
+	bestMiss := math.Inf(1)
+	for attempt := 0; attempt < spawnAttempts; attempt++ {
+		r := runner
+		if attempt > 0 && m.runnerSpawn == nil {
+			r = randomSpawn(&m.mapData, rng, region)
+		}
+
+		c, miss, found := spawnAtDistance(m.mapData, rng, r, d.MinSpawnDistance, d.MaxSpawnDistance)
+		if found && miss < bestMiss {
+			runner, chaser, bestMiss = r, c, miss
+		}
+		if bestMiss == 0 {
+			break
+		}
+	}
+
+	if math.IsInf(bestMiss, 1) {
+		// nothing can be reached from any runner spawn
+		return runner, randomSpawn(&m.mapData, rng, region, runner)
+	}
+	return runner, chaser
+}
+
+// spawnAtDistance picks a chaser spawn among the cells whose shortest path from the runner misses the distance
+// range by the least, preferring open space like getRandomStartPosition. found is false when no other cell can
+// be reached from the runner.
+func spawnAtDistance(mapData [][]int, rng *rand.Rand, runner pixel.Vec, minDistance float64, maxDistance float64) (spawn pixel.Vec, miss float64, found bool) {
+	start := pathfinding.CellOf(runner)
+
+	var candidates []pathfinding.Cell
+	miss = math.Inf(1)
+	for c, distance := range pathfinding.Distances(mapData, start, pathOptions) {
+		// paths lead through doors, but nobody spawns in a doorway
+		if c == start || mapData[c.X][c.Y] != 0 {
+			continue
+		}
+
+		m := math.Max(minDistance-distance, 0)
+		if maxDistance > 0 {
+			m = math.Max(m, distance-maxDistance)
+		}
+		if m < miss {
+			miss = m
+			candidates = candidates[:0]
+		}
+		if m == miss {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		return pixel.Vec{}, 0, false
+	}
+
+	// map iteration order is random, sort so the same seed picks the same cell
+	sortCells(candidates)
+
+	for radius := 2; radius >= 0; radius-- {
+		var open []pathfinding.Cell
+		for _, c := range candidates {
+			if emptyWithin(&mapData, c.X, c.Y, radius) {
+				open = append(open, c)
+			}
+		}
+		if len(open) == 0 {
+			continue
+		}
+
+		c := open[rng.Intn(len(open))]
+		if radius < 2 {
+			return c.Center(), miss, true
+		}
+		return pixel.V(float64(c.X), float64(c.Y)), miss, true
+	}
+
+	return candidates[rng.Intn(len(candidates))].Center(), miss, true
+}
+
+func sortCells(cells []pathfinding.Cell) {
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].X != cells[j].X {
+			return cells[i].X < cells[j].X
+		}
+		return cells[i].Y < cells[j].Y
+	})
+}
@@ -0,0 +1,66 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+
+	"gameenv_ai/pathfinding"
+)
+
+func TestSpawnDistance(t *testing.T) {
+	ranges := []Difficulty{
+		{MinSpawnDistance: 1, MaxSpawnDistance: 3},
+		{MinSpawnDistance: 10, MaxSpawnDistance: 15},
+		{MinSpawnDistance: 25},
+	}
+
+	for _, name := range []MapGeneratorName{GeneratorDungeon, GeneratorBSP, GeneratorMaze, GeneratorCaves} {
+		generator, _ := NewMapGenerator(name)
+
+		for _, d := range ranges {
+			for seed := int64(1); seed <= 10; seed++ {
+				m := generator.Generate(48, 48, seed)
+				rng := rand.New(rand.NewSource(seed))
+
+				runner, chaser := spawnPlayers(m, rng, d)
+				distance, reached := pathfinding.Distances(m.mapData, pathfinding.CellOf(runner), pathOptions)[pathfinding.CellOf(chaser)]
+				if !reached {
+					t.Fatalf("%s seed %d: the chaser at %v can't be reached from the runner at %v", name, seed, chaser, runner)
+				}
+				if distance < d.MinSpawnDistance || (d.MaxSpawnDistance > 0 && distance > d.MaxSpawnDistance) {
+					t.Fatalf("%s seed %d: spawns are %v apart, expected %v-%v", name, seed, distance, d.MinSpawnDistance, d.MaxSpawnDistance)
+				}
+			}
+		}
+	}
+}
+
+func TestSpawnDistanceOutOfReach(t *testing.T) {
+	// no two cells of a 12x12 map are 1000 apart, the spawns end up as far apart as possible
+	m := DungeonGenerator{Rooms: 1}.Generate(12, 12, 1)
+	runner, chaser := spawnPlayers(m, rand.New(rand.NewSource(1)), Difficulty{MinSpawnDistance: 1000})
+	if pathfinding.CellOf(runner) == pathfinding.CellOf(chaser) {
+		t.Fatalf("both players spawned in %v", pathfinding.CellOf(runner))
+	}
+}
+
+func TestDifficultyApply(t *testing.T) {
+	complexity := 0.25
+	d := Difficulty{Rooms: 3, MazeComplexity: &complexity}
+
+	if g := d.Apply(DungeonGenerator{Rooms: 8}).(DungeonGenerator); g.Rooms != 3 {
+		t.Fatalf("dungeon rooms = %d, expected 3", g.Rooms)
+	}
+	if g := d.Apply(ScatterGenerator{}).(ScatterGenerator); g.Rooms != 3 {
+		t.Fatalf("scatter rooms = %d, expected 3", g.Rooms)
+	}
+	if g := d.Apply(MazeGenerator{}).(MazeGenerator); g.Loops != 0.75 {
+		t.Fatalf("maze loops = %v, expected 0.75", g.Loops)
+	}
+
+	for _, bad := range []Difficulty{{MapSize: 5}, {MapSize: 1000}, {Rooms: -1}, {MinSpawnDistance: 5, MaxSpawnDistance: 2}} {
+		if bad.Validate() == nil {
+			t.Errorf("%+v: expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package game
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// Door cells (3) hold a door that slides sideways into the wall, like in Wolf3D. A door is opened with
+// RLActionUse, blocks players until it has slid fully open, and closes again after a while unless a
+// player stands in the doorway. Rays see the door as a thin wall recessed to the middle of its cell.
+
+// door - the state of a door cell
+type door struct {
+	x, y      int
+	open      float64 // how far the door has slid open, 0 closed to 1 fully open
+	opening   bool    // the door slides open, otherwise it slides closed
+	openTicks int64   // ticks the door has been fully open
+}
+
+const (
+	doorSlideSeconds = 0.1 // simulated seconds the door takes to slide fully open or closed
+	doorStaySeconds  = 5.0 // simulated seconds a fully open door waits before it closes
+	doorUseReach     = 1.0 // grid cells in front of a player a door can be used from
+	doorTexture      = 2   // texture of the door slab
+)
+
+// passable reports whether players can walk through the door
+func (d *door) passable() bool {
+	return d.open >= 1
+}
+
+// resetDoors closes every door cell of the new episode's map
+func (g *GameInstance) resetDoors() {
+	g.doorStates = make(map[[2]int]*door)
+	for i, row := range g.mapData {
+		for j, cell := range row {
+			if cell == 3 {
+				g.doorStates[[2]int{i, j}] = &door{x: i, y: j}
+			}
+		}
+	}
+}
+
+// doorAt returns the door of a cell, nil when the cell holds none
+func (g *GameInstance) doorAt(x int, y int) *door {
+	return g.doorStates[[2]int{x, y}]
+}
+
+// isOpenCell reports whether a player can enter the cell: floor, or a door that has slid fully open
+func (g *GameInstance) isOpenCell(x int, y int) bool {
+	if x < 0 || y < 0 || x >= len(g.mapData) || y >= len(g.mapData[x]) {
+		return false
+	}
+	switch g.mapData[x][y] {
+	case 0:
+		return true
+	case 3:
+		d := g.doorAt(x, y)
+		return d != nil && d.passable()
+	}
+	return false
+}
+
+// isOpenAt is isOpenCell for a world position
+func (g *GameInstance) isOpenAt(p pixel.Vec) bool {
+	if p.X < 0 || p.Y < 0 {
+		return false
+	}
+	return g.isOpenCell(int(p.X), int(p.Y))
+}
+
+// hasLineOfSight samples the segment between a and b and reports whether it only crosses empty cells and
+// open doors
+func (g *GameInstance) hasLineOfSight(a, b pixel.Vec) bool {
+	const stepSize = 0.1
+
+	d := b.Sub(a)
+	steps := int(d.Len() / stepSize)
+	for i := 1; i < steps; i++ {
+		if !g.isOpenAt(a.Add(d.Scaled(float64(i) / float64(steps)))) {
+			return false
+		}
+	}
+	return true
+}
+
+// doorAhead returns the door within reach in front of a view, nil when a wall or nothing comes first
+func (g *GameInstance) doorAhead(view *RenderView) *door {
+	const stepSize = 0.25
+
+	for reach := stepSize; reach <= doorUseReach; reach += stepSize {
+		p := view.position.Add(view.direction.Unit().Scaled(reach))
+		if p.X < 0 || p.Y < 0 || int(p.X) >= len(g.mapData) || int(p.Y) >= len(g.mapData[int(p.X)]) {
+			return nil
+		}
+		switch g.mapData[int(p.X)][int(p.Y)] {
+		case 0:
+			continue
+		case 3:
+			return g.doorAt(int(p.X), int(p.Y))
+		}
+		return nil
+	}
+	return nil
+}
+
+// useDoor opens the door in front of a view, or closes it when it is already open or opening
+func (g *GameInstance) useDoor(view *RenderView) {
+	d := g.doorAhead(view)
+	if d == nil {
+		return
+	}
+
+	if !d.opening {
+		d.opening = true
+		d.openTicks = 0
+	} else if !g.isDoorOccupied(d) {
+		d.opening = false
+	}
+}
+
+// isDoorOccupied reports whether a player stands in the doorway, the door can't close on it
+func (g *GameInstance) isDoorOccupied(d *door) bool {
+	for _, p := range []pixel.Vec{g.player1Controller.player.getPosition(), g.player2Controller.player.getPosition()} {
+		if int(math.Floor(p.X)) == d.x && int(math.Floor(p.Y)) == d.y {
+			return true
+		}
+	}
+	return false
+}
+
+// updateDoors slides the doors for the ticks that passed and closes the doors that stayed open long enough
+func (g *GameInstance) updateDoors(ticks int64) {
+	slide := float64(ticks) / math.Max(float64(g.secondsToTicks(doorSlideSeconds)), 1)
+	stay := g.secondsToTicks(doorStaySeconds)
+
+	for _, d := range g.doorStates {
+		switch {
+		case d.opening && d.open < 1:
+			d.open = math.Min(d.open+slide, 1)
+		case d.opening:
+			d.openTicks += ticks
+			if d.openTicks >= stay && !g.isDoorOccupied(d) {
+				d.opening = false
+			}
+		case d.open > 0:
+			d.open = math.Max(d.open-slide, 0)
+		}
+	}
+}
+
+// throughDoors lets a scripted controller walk through doors: a forward move into a closed door opens it
+// instead, and the controller waits while the door slides open
+func (g *GameInstance) throughDoors(view *RenderView, action RLAction) RLAction {
+	if action != RLActionMoveForward {
+		return action
+	}
+
+	d := g.doorAhead(view)
+	if d == nil || d.passable() {
+		return action
+	}
+	if d.opening {
+		return RLActionNone
+	}
+	return RLActionUse
+}
+
+// HangDoors puts a closed door (3) into every recorded doorway that is a single cell wide: a floor cell
+// between two walls, with floor on the other two sides. Returns the number of doors hung.
+func (m *Map) HangDoors() int {
+	wall := func(x int, y int) bool {
+		return x < 0 || y < 0 || x >= len(m.mapData) || y >= len(m.mapData[x]) || (m.mapData[x][y] != 0 && m.mapData[x][y] != 3)
+	}
+	floor := func(x int, y int) bool {
+		return !wall(x, y) && m.mapData[x][y] == 0
+	}
+
+	hung := 0
+	for _, d := range m.doors {
+		if len(d) != 2 || wall(d[0], d[1]) || m.mapData[d[0]][d[1]] != 0 {
+			continue
+		}
+		x, y := d[0], d[1]
+		acrossX := wall(x-1, y) && wall(x+1, y) && floor(x, y-1) && floor(x, y+1)
+		acrossY := wall(x, y-1) && wall(x, y+1) && floor(x-1, y) && floor(x+1, y)
+		if acrossX || acrossY {
+			m.mapData[x][y] = 3
+			hung++
+		}
+	}
+	return hung
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// doorGame returns an instance on a map split by a wall with a doorway at 2,3, the runner facing the doorway
+func doorGame(t *testing.T) *GameInstance {
+	f := mapFile{Version: mapFileVersion, Grid: []string{
+		"#######",
+		"#..=..#",
+		"#.....#",
+		"#..=..#",
+		"#######",
+	}, Doors: [][]int{{2, 3}, {2, 1}}}
+	m, err := f.toMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2,1 has floor on three sides, it isn't a doorway
+	if n := m.HangDoors(); n != 1 || m.mapData[2][3] != 3 || m.mapData[2][1] != 0 {
+		t.Fatalf("hung %d doors", n)
+	}
+
+	g := &GameInstance{mapData: m.mapData}
+	g.resetDoors()
+	runner := &Player{game: g, view: &RenderView{position: pixel.V(2.5, 1.5), direction: pixel.V(0, 1), plane: pixel.V(0.66, 0)}}
+	chaser := &Enemy{game: g, view: &RenderView{position: pixel.V(1.5, 5.5), direction: pixel.V(1, 0), plane: pixel.V(0, 0.66)}}
+	g.player1Controller = &PlayerController{player: runner}
+	g.player2Controller = &EnemyController{player: chaser}
+	runner.view.distanceToWall = 1
+	return g
+}
+
+func TestDoors(t *testing.T) {
+	g := doorGame(t)
+	runner := g.player1Controller
+	walk := func(steps int) {
+		for i := 0; i < steps; i++ {
+			runner.moveForward(0.5)
+			g.updateDoors(g.ticksPerStep())
+		}
+	}
+
+	// a closed door blocks the way
+	walk(4)
+	if runner.player.view.position.Y >= 3 {
+		t.Fatalf("walked into a closed door, at %v", runner.player.view.position)
+	}
+	if g.hasLineOfSight(pixel.V(2.5, 1.5), pixel.V(2.5, 5.5)) {
+		t.Fatal("saw through a closed door")
+	}
+
+	// the door only lets the runner through once it has slid open
+	g.useDoor(runner.player.view)
+	d := g.doorAt(2, 3)
+	if !d.opening || d.passable() {
+		t.Fatalf("door didn't start opening: %+v", d)
+	}
+	for i := 0; !d.passable(); i++ {
+		if i > 100 {
+			t.Fatal("door never opened")
+		}
+		if g.isOpenCell(2, 3) {
+			t.Fatal("door passable while sliding")
+		}
+		g.updateDoors(g.ticksPerStep())
+	}
+	walk(3)
+	if runner.player.view.position.Y < 4 {
+		t.Fatalf("didn't walk through the open door, at %v", runner.player.view.position)
+	}
+
+	// the door closes by itself, but not on a player in the doorway
+	runner.player.view.position = pixel.V(2.5, 3.5)
+	g.updateDoors(g.secondsToTicks(doorStaySeconds))
+	if !d.opening {
+		t.Fatal("door closed on the runner")
+	}
+	runner.player.view.position = pixel.V(2.5, 4.5)
+	g.updateDoors(g.secondsToTicks(doorStaySeconds))
+	g.updateDoors(g.secondsToTicks(doorSlideSeconds))
+	if d.opening || d.open != 0 {
+		t.Fatalf("door didn't close: %+v", d)
+	}
+}
+
+func TestThroughDoors(t *testing.T) {
+	g := doorGame(t)
+	view := g.player1Controller.player.view
+	view.position = pixel.V(2.5, 2.5)
+
+	if a := g.throughDoors(view, RLActionMoveForward); a != RLActionUse {
+		t.Fatalf("expected the closed door to be opened, got %d", a)
+	}
+	g.useDoor(view)
+	if a := g.throughDoors(view, RLActionMoveForward); a != RLActionNone {
+		t.Fatalf("expected to wait for the door, got %d", a)
+	}
+	g.updateDoors(g.secondsToTicks(doorSlideSeconds))
+	if a := g.throughDoors(view, RLActionMoveForward); a != RLActionMoveForward {
+		t.Fatalf("expected to walk through the open door, got %d", a)
+	}
+	if a := g.throughDoors(view, RLActionTurnLeft); a != RLActionTurnLeft {
+		t.Fatalf("turns must pass unchanged, got %d", a)
+	}
+}
+
+func TestInstanceDoors(t *testing.T) {
+	g := newTestGame(t, 64, 48)
+	g.Generator = GeneratorDungeon
+	g.Doors = true
+
+	cfg := g.ResetWithConfig(ResetConfig{})
+	if cfg.Difficulty == nil || !cfg.Difficulty.Doors {
+		t.Fatalf("applied config %+v doesn't report the doors", cfg)
+	}
+	if len(g.doorStates) == 0 {
+		t.Fatal("no doors were hung")
+	}
+}
@@ -0,0 +1,143 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DungeonGenerator - rooms of random size scattered over solid rock, connected by corridors
+type DungeonGenerator struct {
+	Rooms         int // rooms to place, fewer are placed when the map runs out of space
+	MinRoomSize   int // smallest room floor, in cells along each side
+	MaxRoomSize   int // largest room floor, in cells along each side
+	CorridorWidth int // width of the corridors between the rooms, in cells
+}
+
+// DefaultDungeonGenerator is used for every parameter left at zero
+var DefaultDungeonGenerator = DungeonGenerator{Rooms: 8, MinRoomSize: 4, MaxRoomSize: 9, CorridorWidth: 1}
+
+const (
+	roomPlacementAttempts = 200    // bounds the tries to find free space for each room
+	maxRoomSize           = 48 - 4 // largest room whose walls fit inside the boundary of a 48x48 map
+)
+
+// withDefaults returns the generator with every parameter left at zero set to its default
+func (d DungeonGenerator) withDefaults() DungeonGenerator {
+	if d.Rooms == 0 {
+		d.Rooms = DefaultDungeonGenerator.Rooms
+	}
+	if d.MinRoomSize == 0 {
+		d.MinRoomSize = DefaultDungeonGenerator.MinRoomSize
+	}
+	if d.MaxRoomSize == 0 {
+		d.MaxRoomSize = DefaultDungeonGenerator.MaxRoomSize
+	}
+	if d.CorridorWidth == 0 {
+		d.CorridorWidth = DefaultDungeonGenerator.CorridorWidth
+	}
+	return d
+}
+
+// Validate returns an error when the parameters can't be generated
+func (d DungeonGenerator) Validate() error {
+	d = d.withDefaults()
+	if d.Rooms < 1 {
+		return errors.New("a dungeon needs at least one room")
+	}
+	if d.MinRoomSize < 1 || d.MaxRoomSize < d.MinRoomSize {
+		return fmt.Errorf("invalid room size range %d-%d", d.MinRoomSize, d.MaxRoomSize)
+	}
+	if d.MaxRoomSize > maxRoomSize {
+		return fmt.Errorf("rooms of size %d don't fit the map", d.MaxRoomSize)
+	}
+	if d.CorridorWidth < 1 || d.CorridorWidth > d.MinRoomSize {
+		return fmt.Errorf("corridor width must be between 1 and the smallest room size, got %d", d.CorridorWidth)
+	}
+	return nil
+}
+
+// Generate fills the map with rooms connected by corridors. Solid rock is wall (2), the walls of the rooms
+// are room walls (4) and the openings where corridors enter a room are recorded as doors. Every floor cell
+// is reachable from every other one.
+func (d DungeonGenerator) Generate(rows int, cols int, seed int64) *Map {
+	d = d.withDefaults()
+
+	m := newRockMap(rows, cols, seed)
+
+	rooms := m.placeRooms(d)
+	for _, r := range rooms {
+		m.addRoom(r)
+	}
+
+	// Join every room to the closest room that is already connected, which spans all rooms
+	connected := []room{rooms[0]}
+	remaining := append([]room(nil), rooms[1:]...)
+	for len(remaining) > 0 {
+		best, bestFrom, bestDistance := 0, 0, -1
+		for i, r := range remaining {
+			for j, c := range connected {
+				if distance := roomDistance(r, c); bestDistance < 0 || distance < bestDistance {
+					best, bestFrom, bestDistance = i, j, distance
+				}
+			}
+		}
+
+		m.carveCorridor(connected[bestFrom], remaining[best], d.CorridorWidth)
+
+		connected = append(connected, remaining[best])
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+
+	// Corridors are carved from room center to room center, so there should never be a closed off
+	// pocket; seal any that is left so the guarantee holds no matter how the rooms were placed
+	cx, cy := rooms[0].center()
+	m.sealUnreachable(cx, cy)
+
+	return m
+}
+
+// placeRooms places up to d.Rooms rooms that don't touch each other, always at least one
+func (m *Map) placeRooms(d DungeonGenerator) []room {
+	var rooms []room
+	for len(rooms) < d.Rooms {
+		placed := false
+		for attempt := 0; attempt < roomPlacementAttempts && !placed; attempt++ {
+			w := d.MinRoomSize + m.rng.Intn(d.MaxRoomSize-d.MinRoomSize+1)
+			h := d.MinRoomSize + m.rng.Intn(d.MaxRoomSize-d.MinRoomSize+1)
+
+			// keep the room walls off the boundary
+			if w > m.rows-4 || h > m.cols-4 {
+				continue
+			}
+			r := room{x: 2 + m.rng.Intn(m.rows-3-w), y: 2 + m.rng.Intn(m.cols-3-h), w: w, h: h}
+
+			placed = true
+			for _, o := range rooms {
+				if r.overlaps(o) {
+					placed = false
+					break
+				}
+			}
+			if placed {
+				rooms = append(rooms, r)
+			}
+		}
+
+		if !placed {
+			break
+		}
+	}
+
+	if len(rooms) == 0 {
+		// not even the smallest room fits, use all the space inside the boundary
+		rooms = append(rooms, room{x: 2, y: 2, w: m.rows - 4, h: m.cols - 4})
+	}
+
+	return rooms
+}
+
+func roomDistance(a room, b room) int {
+	ax, ay := a.center()
+	bx, by := b.center()
+	return abs(ax-bx) + abs(ay-by)
+}
@@ -0,0 +1,69 @@
+package game
+
+import (
+	"testing"
+
+	"gameenv_ai/pathfinding"
+)
+
+func TestGenerateDungeonIsConnected(t *testing.T) {
+	generators := []DungeonGenerator{
+		{},
+		{Rooms: 1},
+		{Rooms: 30, MinRoomSize: 3, MaxRoomSize: 5},
+		{Rooms: 4, MinRoomSize: 10, MaxRoomSize: 20, CorridorWidth: 3},
+		{Rooms: 12, MinRoomSize: 2, MaxRoomSize: 2, CorridorWidth: 2},
+		{MinRoomSize: 44, MaxRoomSize: 44},
+	}
+
+	for _, d := range generators {
+		if err := d.Validate(); err != nil {
+			t.Fatalf("%+v: %v", d, err)
+		}
+
+		for seed := int64(1); seed <= 50; seed++ {
+			m := d.Generate(48, 48, seed)
+
+			var floor []pathfinding.Cell
+			for i, row := range m.mapData {
+				for j, cell := range row {
+					if cell == 0 {
+						floor = append(floor, pathfinding.Cell{X: i, Y: j})
+					}
+					if cell == 0 && (i == 0 || j == 0 || i == m.rows-1 || j == m.cols-1) {
+						t.Fatalf("%+v seed %d: floor on the boundary at %d,%d", d, seed, i, j)
+					}
+				}
+			}
+			if len(floor) == 0 {
+				t.Fatalf("%+v seed %d: no floor", d, seed)
+			}
+
+			seen := reachable(m.mapData, floor[0])
+			for _, c := range floor {
+				if !seen[c] {
+					t.Fatalf("%+v seed %d: %v can't be reached from %v", d, seed, c, floor[0])
+				}
+			}
+
+			for _, door := range m.doors {
+				if m.mapData[door[0]][door[1]] != 0 {
+					t.Fatalf("%+v seed %d: door %v isn't open", d, seed, door)
+				}
+			}
+		}
+	}
+}
+
+func TestDungeonGeneratorValidate(t *testing.T) {
+	for _, d := range []DungeonGenerator{
+		{Rooms: -1},
+		{MinRoomSize: 6, MaxRoomSize: 5},
+		{MaxRoomSize: 45},
+		{MinRoomSize: 2, MaxRoomSize: 4, CorridorWidth: 3},
+	} {
+		if d.Validate() == nil {
+			t.Errorf("%+v: expected an error", d)
+		}
+	}
+}
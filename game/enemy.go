@@ -26,3 +26,38 @@ func (p *Enemy) getRotation() pixel.Vec {
 func (p *Enemy) getPlane() pixel.Vec {
 	return p.view.plane
 }
+
+// getReward rewards the chaser for closing in on the runner, most of all for catching it
+func (p *Enemy) getReward() float32 {
+	runner := p.game.player1Controller.player
+	distance := p.view.position.Sub(runner.view.position).Len()
+
+	previousDistance := p.game.previousEucDistance
+	p.game.previousEucDistance = distance
+
+	if runner.isDone() {
+		return 2
+	}
+
+	if p.view.distanceToWall < 1 {
+		return -1
+	}
+
+	if previousDistance == 0 {
+		return 0
+	}
+
+	if distance < previousDistance && p.view.isOtherPlayerSpriteVisible {
+		return 1
+	} else if distance < previousDistance {
+		return 0.5
+	}
+
+	return 0
+}
+
+// getIntensityValuesAroundPlayer returns the runner's sound intensity in the cells around the chaser
+func (p *Enemy) getIntensityValuesAroundPlayer() [][]float64 {
+	runner := p.game.player1Controller.player
+	return intensityAround(p.game.mapData, p.getPosition(), runner.getPosition())
+}
@@ -1,14 +1,96 @@
 package game
 
 import (
-    "time"
+	"math"
+	"time"
+
+	"github.com/faiface/pixel"
 )
 
 type EnemyController struct {
-    player              *Enemy
-    lastDirectionChange time.Time
+	player              *Enemy
+	lastDirectionChange time.Time
+
+	// Scripted behaviour state, see chaser.go
+	behaviour   ChaserBehaviour
+	path        []pixel.Vec
+	patrolIndex int
+	lastSeen    pixel.Vec
+	hasLastSeen bool
+	walkTurn    RLAction
+}
+
+// update is called every frame. Scripted behaviours act once per simulated step instead, see act.
+func (c *EnemyController) update(delta float64) {
+
+}
+
+// applyAction moves the chaser with the same step sizes TakePlayer1Action uses for the runner
+func (c *EnemyController) applyAction(action RLAction) {
+	switch action {
+	case RLActionMoveForward:
+		c.moveForward(0.5)
+	case RLActionMoveBackward:
+		c.moveBackwards(0.5)
+	case RLActionStrafeLeft:
+		c.moveLeft(0.5)
+	case RLActionStrafeRight:
+		c.moveRight(0.5)
+	case RLActionTurnLeft:
+		c.turnLeft(0.1)
+	case RLActionTurnRight:
+		c.turnRight(0.1)
+	case RLActionUse:
+		c.player.game.useDoor(c.player.view)
+	}
+}
+
+func (c *EnemyController) moveForward(s float64) {
+	c.move(c.player.view.direction.X*s, c.player.view.direction.Y*s)
+}
+
+func (c *EnemyController) moveBackwards(s float64) {
+	c.move(-c.player.view.direction.X*s, -c.player.view.direction.Y*s)
+}
+
+func (c *EnemyController) moveLeft(s float64) {
+	c.move(-c.player.view.plane.X*s, -c.player.view.plane.Y*s)
+}
+
+func (c *EnemyController) moveRight(s float64) {
+	c.move(c.player.view.plane.X*s, c.player.view.plane.Y*s)
+}
+
+// move slides along walls, each axis is only moved when the cell it moves into is empty or an open door
+func (c *EnemyController) move(dx, dy float64) {
+	g := c.player.game
+	position := &c.player.view.position
+
+	if g.isOpenCell(int(position.X+dx), int(position.Y)) {
+		position.X += dx
+	}
+
+	if g.isOpenCell(int(position.X), int(position.Y+dy)) {
+		position.Y += dy
+	}
+}
+
+func (c *EnemyController) turnLeft(s float64) {
+	c.rotate(s)
 }
 
-func (c EnemyController) update(delta float64) {
+func (c *EnemyController) turnRight(s float64) {
+	c.rotate(-s)
+}
+
+func (c *EnemyController) rotate(s float64) {
+	view := c.player.view
+
+	oldDirX := view.direction.X
+	view.direction.X = view.direction.X*math.Cos(s) - view.direction.Y*math.Sin(s)
+	view.direction.Y = oldDirX*math.Sin(s) + view.direction.Y*math.Cos(s)
 
+	oldPlaneX := view.plane.X
+	view.plane.X = view.plane.X*math.Cos(s) - view.plane.Y*math.Sin(s)
+	view.plane.Y = oldPlaneX*math.Sin(s) + view.plane.Y*math.Cos(s)
 }
@@ -0,0 +1,189 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+// An episode log holds everything needed to re-simulate an episode: the reset config with its
+// seed, the clock settings, the initial poses and the action of every step. The reward, done
+// flag and poses of every step are kept too, so a replay can verify it reproduced the episode.
+// Only steps taken through TakePlayer1Action are logged, keyboard play can't be replayed.
+
+const episodeLogVersion = 1
+
+// EpisodeLog - a recorded episode, written as json by the server
+type EpisodeLog struct {
+	Version        int
+	Config         ResetConfig // the applied config, including the seed
+	TicksPerStep   int64
+	SecondsPerTick float64
+	RenderWidth    int // size of the views, logs without it were rendered at 640x480
+	RenderHeight   int
+	Lighting       bool   // the views were lit by the light map
+	WallShading    bool   // the walls were shaded with the normal and displacement maps
+	StartTick      int64  // simulated tick the episode started at
+	MapChecksum    string // detects a map generator that no longer produces the same map
+	Runner         Pose   // initial poses
+	Chaser         Pose
+	Steps          []EpisodeLogStep
+}
+
+// EpisodeLogStep - one step of a logged episode
+type EpisodeLogStep struct {
+	Action       RLAction
+	ChaserAction *RLAction `json:",omitempty"` // set when a remote agent drove the chaser in lockstep
+	Reward       float32
+	Done         bool
+	Truncated    bool
+	Runner       Pose // poses after the step
+	Chaser       Pose
+}
+
+// mapChecksum hashes the map cells
+func mapChecksum(mapData [][]int) string {
+	h := fnv.New64a()
+	for _, row := range mapData {
+		for _, cell := range row {
+			h.Write([]byte{byte(cell), byte(cell >> 8)})
+		}
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// beginEpisodeLog starts logging the episode that was just reset
+func (g *GameInstance) beginEpisodeLog(cfg ResetConfig) {
+	g.finishEpisodeLog()
+
+	if g.EpisodeLogDir == "" {
+		return
+	}
+
+	g.episodeLog = &EpisodeLog{
+		Version:        episodeLogVersion,
+		Config:         cfg,
+		TicksPerStep:   g.ticksPerStep(),
+		SecondsPerTick: g.secondsPerTick(),
+		RenderWidth:    g.RenderWidth,
+		RenderHeight:   g.RenderHeight,
+		Lighting:       g.Lighting,
+		WallShading:    g.WallShading,
+		StartTick:      g.episodeStartTick,
+		MapChecksum:    mapChecksum(g.mapData),
+		Runner:         poseOf(g.player1Controller.player.view),
+		Chaser:         poseOf(g.player2Controller.player.view),
+	}
+	g.episodeLogEpisode = g.episodeCount
+}
+
+// logEpisodeStep appends a runner step, the episode is written once it is done
+func (g *GameInstance) logEpisodeStep(action RLAction, result RLActionResult) {
+	chaserAction := g.loggedChaserAction
+	g.loggedChaserAction = nil
+
+	if g.episodeLog == nil {
+		return
+	}
+
+	g.episodeLog.Steps = append(g.episodeLog.Steps, EpisodeLogStep{
+		Action:       action,
+		ChaserAction: chaserAction,
+		Reward:       result.Reward,
+		Done:         result.Done,
+		Truncated:    result.Truncated,
+		Runner:       poseOf(g.player1Controller.player.view),
+		Chaser:       poseOf(g.player2Controller.player.view),
+	})
+
+	if result.Done {
+		g.finishEpisodeLog()
+	}
+}
+
+// abortEpisodeLog drops the current episode's log, after a step that can't be replayed
+func (g *GameInstance) abortEpisodeLog() {
+	if g.episodeLog != nil {
+		log.Println("Episode ", g.episodeLogEpisode, " was played from the keyboard, it isn't logged")
+	}
+	g.episodeLog = nil
+}
+
+// finishEpisodeLog writes the current episode's log, if it has any steps
+func (g *GameInstance) finishEpisodeLog() {
+	episodeLog := g.episodeLog
+	g.episodeLog = nil
+
+	if episodeLog == nil || len(episodeLog.Steps) == 0 {
+		return
+	}
+
+	name := filepath.Join(g.EpisodeLogDir, fmt.Sprintf("episode-%06d-%d.json", g.episodeLogEpisode, *episodeLog.Config.Seed))
+	if err := episodeLog.Save(name); err != nil {
+		log.Println("Error writing episode log: ", err)
+	}
+}
+
+// Save writes the log as json
+func (l *EpisodeLog) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// LoadEpisodeLog reads a log written by the server
+func LoadEpisodeLog(path string) (*EpisodeLog, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var l EpisodeLog
+	if err := json.Unmarshal(b, &l); err != nil {
+		return nil, err
+	}
+	if l.Version != episodeLogVersion {
+		return nil, fmt.Errorf("unsupported episode log version %d", l.Version)
+	}
+	if l.Config.Seed == nil {
+		return nil, errors.New("episode log has no seed")
+	}
+	return &l, nil
+}
+
+// Map rebuilds the map the episode was played on without re-simulating it, maps are the fixed maps the
+// episode may have been played on, like ReplayOptions.Maps
+func (l *EpisodeLog) Map(maps []*Map) (*Map, error) {
+	if l.Config.Seed == nil {
+		return nil, errors.New("episode log has no seed")
+	}
+
+	g := &GameInstance{Maps: maps}
+	if err := g.CheckResetConfig(l.Config); err != nil {
+		return nil, err
+	}
+
+	cfg := l.Config
+	var difficulty Difficulty
+	if cfg.Difficulty != nil {
+		difficulty = *cfg.Difficulty
+	}
+
+	g.rng = rand.New(rand.NewSource(*cfg.Seed))
+	m := g.episodeMap(&cfg, difficulty)
+	if checksum := mapChecksum(m.mapData); checksum != l.MapChecksum {
+		return nil, fmt.Errorf("map checksum %s doesn't match the logged %s", checksum, l.MapChecksum)
+	}
+	return m, nil
+}
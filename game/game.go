@@ -1,23 +1,20 @@
 package game
 
 import (
-	"encoding/json"
+	"errors"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 	"image"
-	"image/color"
-	"log"
 	"math"
 	"math/rand"
-	"os"
-	"time"
 )
 
 type GameInstance struct {
-	win         *pixelgl.Window
-	cfg         pixelgl.WindowConfig
+	win         *window // nil in headless instances, see window.go
 	mapData     [][]int
 	lights      []LightSource
+	doors       [][]int          // door cells of the generated rooms
+	doorStates  map[[2]int]*door // state of every door cell (3) of the episode, see door.go
+	lightMap    *lightMap        // light of the episode's light sources, nil unless Lighting is set
 	textureData []byte
 	textureMap  *image.RGBA
 	normalMap   *image.RGBA
@@ -34,13 +31,28 @@ type GameInstance struct {
 	player2Controller *EnemyController  //chaser
 
 	// Params
-	RenderWidth      int
+	RenderWidth      int // size of both players' views, the window is RenderScale times larger
 	RenderHeight     int
 	RenderScale      float64
 	RenderFullscreen bool
-
-	currentTick      int64
+	Headless         bool              // never open a window or GL context, observations are only rendered off-screen
+	Seed             int64             // seeds the per-episode seeds, the same seed always replays the same episodes
+	TicksPerStep     int64             // simulated ticks that pass for every action/step
+	SecondsPerTick   float64           // simulated seconds per tick, used to express timeouts in ticks
+	ChaserBehaviour  ChaserBehaviour   // default scripted behaviour of the chaser, a reset config can override it
+	UseAutopilot     bool              // the runner is driven by the autopilot instead of the keyboard in the window, toggled with P
+	Recorder         *Recorder         // when set, every step of the runner is recorded, whichever controller takes it
+	EpisodeLogDir    string            // when set, every episode is logged here for a deterministic replay
+	Maps             []*Map            // fixed levels played instead of generated maps, one is picked per reset
+	Generator        MapGeneratorName  // generator of the maps, a reset config can override it
+	Dungeon          *DungeonGenerator // parameters of the dungeon generator, nil uses the defaults
+	Lighting         bool              // light the views with the map's light sources, baked into a light map at every reset
+	WallShading      bool              // shade the walls per pixel with the normal and displacement maps, see wallshading.go
+	Doors            bool              // hang doors in every episode, like the Doors difficulty parameter of a reset config
+
+	currentTick      int64 // simulated ticks, advanced once per step
 	episodeStartTick int64
+	episodeSteps     int // steps taken in the current episode
 
 	timeBonusStartTick int64
 
@@ -48,104 +60,211 @@ type GameInstance struct {
 	episodeCount                  int
 	lastPlayer1PositionUpdateTick int64
 	lastPlayer1Obs                []float64
-	planPath                      []pixel.Vec
-}
-
-func (g *GameInstance) GameLoop() {
 
-	g.gameInit()
-
-	g.addGameObjects()
+	seedSource  *rand.Rand // produces the seed of each episode
+	rng         *rand.Rand // all randomness within an episode is drawn from here
+	episodeSeed int64
 
-	g.Reset()
+	observationFormat       *ObservationFormat // nil until an agent negotiates a format
+	chaserObservationFormat *ObservationFormat
 
-	last := time.Now()
+	chaserAttached bool // the chaser is driven by a remote agent, see Step
+	lockstep       lockstep
 
-	for !g.win.Closed() {
-		if g.win.JustPressed(pixelgl.KeyEscape) || g.win.JustPressed(pixelgl.KeyQ) {
-			return
-		}
+	runnerAutopilot *Autopilot
+	trail           []pixel.Vec     // positions of the runner in the current episode, drawn by RenderTopDown
+	recordedFrom    *RLActionResult // observation the next recorded step is taken from, see recordStep
 
-		g.win.Clear(color.Black)
+	episodeLog         *EpisodeLog
+	episodeLogEpisode  int
+	loggedChaserAction *RLAction // chaser action of the step in progress, see takeActions
+}
 
-		dt := time.Since(last).Seconds()
-		last = time.Now()
+// ResetConfig - optional overrides applied when resetting an episode
+type ResetConfig struct {
+	Seed            *int64            `json:",omitempty"` // when nil, the next seed from the instance seed is used
+	ChaserBehaviour ChaserBehaviour   `json:",omitempty"` // when empty, the instance's chaser behaviour is used
+	Map             string            `json:",omitempty"` // name of one of the instance's fixed maps, when empty one is picked by seed
+	Generator       MapGeneratorName  `json:",omitempty"` // when empty, the instance's generator is used
+	Dungeon         *DungeonGenerator `json:",omitempty"` // parameters of the dungeon generator, also selects it when no generator is given
+	Difficulty      *Difficulty       `json:",omitempty"` // curriculum parameters of the map and the spawns, nil keeps the defaults
+}
 
-		g.currentTick = last.UnixMilli()
+// Validate returns an error when the config can't be applied
+func (cfg ResetConfig) Validate() error {
+	if cfg.ChaserBehaviour != "" {
+		if err := cfg.ChaserBehaviour.Validate(); err != nil {
+			return err
+		}
+	}
+	if cfg.Generator != "" {
+		if err := cfg.Generator.Validate(); err != nil {
+			return err
+		}
+	}
+	if cfg.Dungeon != nil {
+		if err := cfg.Dungeon.Validate(); err != nil {
+			return err
+		}
+	}
+	if cfg.Difficulty != nil {
+		return cfg.Difficulty.Validate()
+	}
+	return nil
+}
 
-		g.updateGameEntities(dt)
+// CheckResetConfig returns an error when the config can't be applied to this instance
+func (g *GameInstance) CheckResetConfig(cfg ResetConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if cfg.Map != "" && g.findMap(cfg.Map) == nil {
+		return errors.New("unknown map: " + cfg.Map)
+	}
+	return nil
+}
 
-		// Process player input
-		g.player1Controller.processInput(g.win, dt)
+func (g *GameInstance) Reset() {
+	g.ResetWithConfig(ResetConfig{})
+}
 
-		//g.autoPlan(g.player1Controller, g.player2Controller, dt)
+// ResetWithConfig starts a new episode and returns the config that was applied, with the seed filled in.
+func (g *GameInstance) ResetWithConfig(cfg ResetConfig) ResetConfig {
 
-		// Render player1's view
-		if g.renderListener.renderBuffer != nil {
-			g.renderListener.renderBufferMutex.Lock()
-			p := pixel.PictureDataFromImage(g.renderListener.renderBuffer)
-			pixel.NewSprite(p, p.Bounds()).
-				Draw(g.win, pixel.IM.Moved(g.center).Scaled(g.center, g.RenderScale))
-			g.renderListener.renderBufferMutex.Unlock()
-		} else {
-			g.player1Controller.player.view.render()
-		}
+	if g.seedSource == nil {
+		g.seedSource = rand.New(rand.NewSource(g.Seed))
+	}
 
-		g.win.Update()
+	seed := g.seedSource.Int63()
+	if cfg.Seed != nil {
+		seed = *cfg.Seed
 	}
-}
+	cfg.Seed = &seed
 
-func (g *GameInstance) Reset() {
+	g.episodeSeed = seed
+	g.rng = rand.New(rand.NewSource(seed))
 
 	g.episodeCount += 1
-	print("Reset! Episode: ", g.episodeCount, "\n")
-
-	rand.Seed(time.Now().UnixNano())
+	print("Reset! Episode: ", g.episodeCount, " Seed: ", seed, "\n")
 
-	g.episodeStartTick = time.Now().UnixMilli()
+	g.episodeStartTick = g.currentTick
+	g.episodeSteps = 0
+	g.lastPlayer1PositionUpdateTick = g.currentTick
 	g.previousEucDistance = 0
 	g.lastPlayer1Obs = nil
 
-	mapGen := Map{rows: 48, cols: 48}
-	mapGen.GenerateMap()
+	var difficulty Difficulty
+	if cfg.Difficulty != nil {
+		difficulty = *cfg.Difficulty
+	}
+	// the instance's doors go into the applied config, so the episode log replays them
+	if g.Doors && !difficulty.Doors {
+		difficulty.Doors = true
+		cfg.Difficulty = &difficulty
+	}
+
+	mapGen := g.episodeMap(&cfg, difficulty)
 	g.mapData = mapGen.mapData
 	g.lights = mapGen.lights
+	g.doors = mapGen.doors
+	g.resetDoors()
+	g.lightMap = nil
+	if g.Lighting {
+		g.lightMap = bakeLightMap(g.mapData, g.lights)
+	}
 
-	g.player1Controller.player.view.position = getRandomStartPosition(&g.mapData)
-	g.player2Controller.player.view.position = getRandomStartPosition(&g.mapData)
+	g.player1Controller.player.view.position, g.player2Controller.player.view.position = spawnPlayers(mapGen, g.rng, difficulty)
+
+	g.player1Controller.player.old_position = g.player1Controller.player.view.position
+	g.player1Controller.player.is_moving = true
+	g.trail = []pixel.Vec{g.player1Controller.player.view.position}
 
 	g.player1Controller.distanceStack = []float64{}
-}
 
-func (g *GameInstance) gameInit() {
+	g.autopilot().reset()
 
-	g.textureMap = textureToImage("assets/texture.png")
-	g.normalMap = textureToImage("assets/normal.png")
-	g.dispMap = textureToImage("assets/disp.png")
+	g.player2Controller.resetBehaviour(cfg.ChaserBehaviour)
+	if cfg.ChaserBehaviour == "" {
+		cfg.ChaserBehaviour = g.chaserBehaviour()
+	}
 
-	cfg := pixelgl.WindowConfig{
-		Bounds:      pixel.R(0, 0, float64(g.RenderWidth)*g.RenderScale, float64(g.RenderHeight)*g.RenderScale),
-		VSync:       true,
-		Undecorated: false,
-		Resizable:   true,
+	// the first observation and the wall distance belong to the new episode, not the last frame of the previous one
+	g.player1Controller.player.view.render()
+
+	g.beginRecordedEpisode()
+	g.beginEpisodeLog(cfg)
+
+	return cfg
+}
+
+// episodeMap picks or generates the map of a new episode from the episode's rng and fills in the reset config
+// with the map or the generator that is applied
+func (g *GameInstance) episodeMap(cfg *ResetConfig, difficulty Difficulty) *Map {
+	if fixed := g.pickMap(cfg.Map); fixed != nil {
+		played := fixed.copyFor(g.rng)
+		cfg.Map = fixed.Name
+		cfg.Generator = ""
+		cfg.Dungeon = nil
+		if difficulty.Doors {
+			played.HangDoors()
+		}
+		return &played
 	}
 
-	if g.RenderFullscreen {
-		cfg.Monitor = pixelgl.PrimaryMonitor()
+	size := difficulty.mapSize()
+	m := difficulty.Apply(g.generatorFor(cfg)).Generate(size, size, g.rng.Int63())
+	if difficulty.Doors {
+		m.HangDoors()
 	}
+	return m
+}
+
+// EpisodeSeed returns the seed the current episode was generated from
+func (g *GameInstance) EpisodeSeed() int64 {
+	return g.episodeSeed
+}
 
-	win, err := pixelgl.NewWindow(cfg)
-	if err != nil {
-		panic(err)
+// NewHeadlessGame creates a game instance that is ready to be stepped through TakePlayer1Action
+// without ever creating a pixelgl window. Observations are still rendered into the render buffer.
+func NewHeadlessGame(width int, height int, seed int64) *GameInstance {
+	g := &GameInstance{
+		RenderWidth:  width,
+		RenderHeight: height,
+		RenderScale:  1,
+		Seed:         seed,
 	}
 
-	g.center = win.Bounds().Center()
+	g.StartHeadless()
+
+	return g
+}
+
+// StartHeadless sets up an instance whose params are already configured and starts its first episode,
+// like NewHeadlessGame does for an instance with the default params
+func (g *GameInstance) StartHeadless() {
+	g.Headless = true
+
+	g.gameInit()
+
+	g.addGameObjects()
+
+	g.Reset()
+}
 
-	g.win = win
-	g.cfg = cfg
+func (g *GameInstance) gameInit() {
+
+	g.textureMap = textureToImage("assets/texture.png")
+	g.normalMap = textureToImage("assets/normal.png")
+	g.dispMap = textureToImage("assets/disp.png")
 
 	g.renderListener = &RenderListener{}
 	g.renderListener2 = &RenderListener{}
+
+	if g.Headless {
+		return
+	}
+
+	g.openWindow()
 }
 
 func (g *GameInstance) updateGameEntities(timeDelta float64) {
@@ -161,8 +280,8 @@ func (g *GameInstance) getTexNum(x, y int) int {
 func (g *GameInstance) addGameObjects() {
 
 	player1Camera := RenderView{
-		renderWidth:    640,
-		renderHeight:   480,
+		renderWidth:    g.RenderWidth,
+		renderHeight:   g.RenderHeight,
 		position:       pixel.V(0.0, 0.0),
 		direction:      pixel.V(-1.0, 0.0),
 		plane:          pixel.V(0.0, 0.66),
@@ -184,8 +303,8 @@ func (g *GameInstance) addGameObjects() {
 	)
 
 	player2Camera := RenderView{
-		renderWidth:    320,
-		renderHeight:   240,
+		renderWidth:    g.RenderWidth,
+		renderHeight:   g.RenderHeight,
 		position:       pixel.V(0.0, 0.0),
 		direction:      pixel.V(-1.0, 0.0),
 		plane:          pixel.V(0.0, 0.66),
@@ -208,84 +327,109 @@ func (g *GameInstance) addGameObjects() {
 	)
 }
 
+// distToNearestWall returns the distance from the cell of a position to the closest wall cell, cells
+// outside the map count as wall
 func (g *GameInstance) distToNearestWall(position pixel.Vec, threshold float32) float64 {
 	mapData := g.mapData
 
 	// Get the position of the player in the map
-	mapX := int(position.X)
-	mapY := int(position.Y)
-
-	// Scan in concentric circles around the player, looking for a wall
-	// if no wall is found, expand the circle by 1 cell
-	for i := 0; i < 100; i++ {
+	mapX := int(math.Floor(position.X))
+	mapY := int(math.Floor(position.Y))
+
+	// Scan squares of growing size around the player, looking for a wall. A wall in a
+	// larger square can still be closer than a corner of the last one, so keep going
+	// until the square is further out than the nearest wall found
+	nearest := 9999.0
+	for i := 0; i < 100 && float64(i) < nearest; i++ {
 		for y := -i; y <= i; y++ {
 			for x := -i; x <= i; x++ {
-				if mapData[mapX+x][mapY+y] > 0 {
-
-					// Calculate the distance to the wall
-					dist := math.Sqrt(math.Pow(float64(x), 2) + math.Pow(float64(y), 2))
+				// the inside of the square was scanned already
+				if abs(x) != i && abs(y) != i {
+					continue
+				}
 
-					return dist
+				cellX, cellY := mapX+x, mapY+y
+				outside := cellX < 0 || cellY < 0 || cellX >= len(mapData) || cellY >= len(mapData[cellX])
+				if outside || mapData[cellX][cellY] > 0 {
+					nearest = math.Min(nearest, math.Sqrt(float64(x*x+y*y)))
 				}
 			}
 		}
 	}
 
-	return 9999
+	return nearest
 }
 
-type ObsRecordSet struct {
-	Obs1   []float64
-	Obs2   []byte
-	Reward float32
-	Done   bool
-	Action int
-}
-
-func (g *GameInstance) recordPlayerSet(action int, reward float32, done bool) {
-	textObservations, imgObservations := g.GetPlayer1Observation()
-
-	if textObservations[0] == 0 && textObservations[1] == 0 && textObservations[2] == 0 {
-		return
+// findMap returns the fixed map with the given name
+func (g *GameInstance) findMap(name string) *Map {
+	for _, m := range g.Maps {
+		if m.Name == name {
+			return m
+		}
 	}
+	return nil
+}
 
-	recordSet := ObsRecordSet{
-		Obs1:   textObservations,
-		Obs2:   imgObservations,
-		Reward: reward,
-		Done:   done,
-		Action: action,
+// pickMap returns the fixed map to play, nil when the instance generates its maps
+func (g *GameInstance) pickMap(name string) *Map {
+	if len(g.Maps) == 0 {
+		return nil
 	}
-
-	// Implement the json serializer method
-	b, err := json.Marshal(recordSet)
-
-	// Open log file and write observation
-	f, err := os.OpenFile("train_data.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Fatal(err)
+	if name != "" {
+		return g.findMap(name)
 	}
-	defer f.Close()
+	return g.Maps[g.rng.Intn(len(g.Maps))]
+}
 
-	if _, err := f.WriteString(string(b) + "\r\n"); err != nil {
-		log.Fatal(err)
+// spawnPosition returns the fixed spawn of a loaded map, or a random one in the region away from the avoided positions
+func spawnPosition(spawn *pixel.Vec, mapData *[][]int, rng *rand.Rand, region [][]bool, avoid ...pixel.Vec) pixel.Vec {
+	if spawn != nil {
+		return *spawn
 	}
+	return randomSpawn(mapData, rng, region, avoid...)
+}
 
+// getRandomStartPosition picks a floor cell with open space around it, other than the cells of the avoided
+// positions. Maps too narrow for that, like mazes, fall back to less and less open space and spawn in the
+// middle of the cell.
+func getRandomStartPosition(mapData *[][]int, rng *rand.Rand, avoid ...pixel.Vec) pixel.Vec {
+	return randomSpawn(mapData, rng, nil, avoid...)
 }
 
-func getRandomStartPosition(mapData *[][]int) pixel.Vec {
+// randomSpawn is getRandomStartPosition limited to the cells of a region, nil allows every floor cell
+func randomSpawn(mapData *[][]int, rng *rand.Rand, region [][]bool, avoid ...pixel.Vec) pixel.Vec {
+	const attempts = 10000
+
 	var x, y int
-	for {
-		x = rand.Intn(len(*mapData))
-		y = rand.Intn(len((*mapData)[0]))
-		if (*mapData)[x][y] == 0 && emptyWithin(mapData, x, y, 2) {
-			break
+	for radius := 2; radius >= 0; radius-- {
+		for i := 0; i < attempts; i++ {
+			x = rng.Intn(len(*mapData))
+			y = rng.Intn(len((*mapData)[0]))
+			if (*mapData)[x][y] != 0 || !emptyWithin(mapData, x, y, radius) || inCellOf(x, y, avoid) || (region != nil && !region[x][y]) {
+				continue
+			}
+
+			// without open space around it, the cell corner may touch a wall, so spawn in the middle of the cell
+			if radius < 2 {
+				return pixel.V(float64(x)+0.5, float64(y)+0.5)
+			}
+			return pixel.V(float64(x), float64(y))
 		}
 	}
 
 	return pixel.V(float64(x), float64(y))
 }
 
+// inCellOf reports whether cell x, y holds one of the positions
+func inCellOf(x int, y int, positions []pixel.Vec) bool {
+	for _, p := range positions {
+		if int(math.Floor(p.X)) == x && int(math.Floor(p.Y)) == y {
+			return true
+		}
+	}
+	return false
+}
+
 func emptyWithin(data *[][]int, x int, y int, radius int) bool {
 	maxX := len((*data)) - 1
 	maxY := len((*data)[0]) - 1
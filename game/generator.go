@@ -0,0 +1,294 @@
+package game
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/faiface/pixel"
+)
+
+// MapGenerator builds the layout of new maps: the cells, the light sources and the doors
+type MapGenerator interface {
+	// Generate returns a map of rows x cols cells surrounded by the boundary wall, the same seed always
+	// yields the same map
+	Generate(rows int, cols int, seed int64) *Map
+}
+
+// MapGeneratorName - one of the built-in map generators
+type MapGeneratorName string
+
+const (
+	GeneratorScatter MapGeneratorName = "scatter" // a few rooms scattered over an open map
+	GeneratorDungeon MapGeneratorName = "dungeon" // rooms connected by corridors
+	GeneratorBSP     MapGeneratorName = "bsp"     // rooms in the leaves of a binary space partition, joined along the tree
+	GeneratorMaze    MapGeneratorName = "maze"    // recursive backtracker maze
+	GeneratorCaves   MapGeneratorName = "caves"   // cellular automata caves
+)
+
+// DefaultMapGenerator is used when neither the instance nor the reset config pick a generator
+const DefaultMapGenerator = GeneratorScatter
+
+// Validate returns an error when the name is not one of the built-in generators
+func (n MapGeneratorName) Validate() error {
+	_, err := NewMapGenerator(n)
+	return err
+}
+
+// NewMapGenerator returns the named generator with its default parameters
+func NewMapGenerator(name MapGeneratorName) (MapGenerator, error) {
+	switch name {
+	case GeneratorScatter:
+		return ScatterGenerator{}, nil
+	case GeneratorDungeon:
+		return DungeonGenerator{}, nil
+	case GeneratorBSP:
+		return BSPGenerator{}, nil
+	case GeneratorMaze:
+		return MazeGenerator{}, nil
+	case GeneratorCaves:
+		return CaveGenerator{}, nil
+	}
+	return nil, errors.New("unknown map generator: " + string(name))
+}
+
+// mapGenerator returns the generator configured on the instance
+func (g *GameInstance) mapGenerator() MapGeneratorName {
+	if g.Generator == "" {
+		return DefaultMapGenerator
+	}
+	return g.Generator
+}
+
+// generatorFor returns the generator of a new episode and fills in the reset config with the generator
+// that is applied. A dungeon config without a generator name selects the dungeon generator.
+func (g *GameInstance) generatorFor(cfg *ResetConfig) MapGenerator {
+	if cfg.Generator == "" {
+		if cfg.Dungeon != nil {
+			cfg.Generator = GeneratorDungeon
+		} else {
+			cfg.Generator = g.mapGenerator()
+		}
+	}
+
+	if cfg.Generator == GeneratorDungeon {
+		if cfg.Dungeon == nil {
+			cfg.Dungeon = g.Dungeon
+		}
+		if cfg.Dungeon != nil {
+			return *cfg.Dungeon
+		}
+		return DungeonGenerator{}
+	}
+
+	cfg.Dungeon = nil
+	generator, err := NewMapGenerator(cfg.Generator)
+	if err != nil {
+		cfg.Generator = DefaultMapGenerator
+		generator, _ = NewMapGenerator(DefaultMapGenerator)
+	}
+	return generator
+}
+
+// ScatterGenerator - the original generator, a few rooms with one door each scattered over an open map
+type ScatterGenerator struct {
+	Rooms int // rooms to place, fewer are placed when the map runs out of space, default 5
+}
+
+func (s ScatterGenerator) Generate(rows int, cols int, seed int64) *Map {
+	m := &Map{rows: rows, cols: cols, rng: rand.New(rand.NewSource(seed))}
+	if s.Rooms == 0 {
+		m.GenerateMap()
+	} else {
+		m.generateScatter(s.Rooms)
+	}
+	return m
+}
+
+// newRockMap returns a map of solid wall (2) inside the boundary, for generators that carve out the floor
+func newRockMap(rows int, cols int, seed int64) *Map {
+	m := &Map{rows: rows, cols: cols, rng: rand.New(rand.NewSource(seed))}
+
+	m.mapData = make([][]int, rows)
+	for i := 0; i < rows; i++ {
+		m.mapData[i] = make([]int, cols)
+		for j := 0; j < cols; j++ {
+			if i == 0 || j == 0 || i == rows-1 || j == cols-1 {
+				m.mapData[i][j] = 1
+			} else {
+				m.mapData[i][j] = 2
+			}
+		}
+	}
+
+	return m
+}
+
+// room is the floor of a room, its walls are the ring of cells around it
+type room struct {
+	x, y, w, h int
+}
+
+func (r room) center() (int, int) {
+	return r.x + r.w/2, r.y + r.h/2
+}
+
+// overlaps reports whether the walls of two rooms would share a cell
+func (r room) overlaps(o room) bool {
+	return r.x-1 <= o.x+o.w && o.x-1 <= r.x+r.w && r.y-1 <= o.y+o.h && o.y-1 <= r.y+r.h
+}
+
+// addRoom digs out the floor of a room, surrounds it with room walls (4) and lights it
+func (m *Map) addRoom(r room) {
+	for i := r.x - 1; i <= r.x+r.w; i++ {
+		for j := r.y - 1; j <= r.y+r.h; j++ {
+			if i < r.x || j < r.y || i == r.x+r.w || j == r.y+r.h {
+				m.mapData[i][j] = 4
+			} else {
+				m.mapData[i][j] = 0
+			}
+		}
+	}
+
+	cx, cy := r.center()
+	radius := float64(r.w+r.h) / 2
+	m.lights = append(m.lights, LightSource{pixel.V(float64(cx)+0.5, float64(cy)+0.5), radius})
+}
+
+// carveCorridor digs an L shaped corridor from the center of one room to the center of the other
+func (m *Map) carveCorridor(from room, to room, width int) {
+	x, y := from.center()
+	tx, ty := to.center()
+
+	horizontalFirst := m.rng.Intn(2) == 0
+	for leg := 0; leg < 2; leg++ {
+		if (leg == 0) == horizontalFirst {
+			for ; y != ty; y += sign(ty - y) {
+				m.carve(x, y, width)
+			}
+		} else {
+			for ; x != tx; x += sign(tx - x) {
+				m.carve(x, y, width)
+			}
+		}
+	}
+	m.carve(x, y, width)
+}
+
+// carve opens a width x width square of cells, recording the room walls it breaks through as doors
+func (m *Map) carve(x int, y int, width int) {
+	for i := x; i < x+width; i++ {
+		for j := y; j < y+width; j++ {
+			if i < 1 || j < 1 || i > m.rows-2 || j > m.cols-2 {
+				continue
+			}
+			if m.mapData[i][j] == 4 {
+				m.doors = append(m.doors, []int{i, j})
+			}
+			m.mapData[i][j] = 0
+		}
+	}
+}
+
+// scatterLights places a light on one in every cellsPerLight floor cells
+func (m *Map) scatterLights(cellsPerLight int, radius float64) {
+	var floor [][2]int
+	for i := range m.mapData {
+		for j := range m.mapData[i] {
+			if m.mapData[i][j] == 0 {
+				floor = append(floor, [2]int{i, j})
+			}
+		}
+	}
+
+	for n := 0; n < len(floor)/cellsPerLight; n++ {
+		c := floor[m.rng.Intn(len(floor))]
+		m.lights = append(m.lights, LightSource{pixel.V(float64(c[0])+0.5, float64(c[1])+0.5), radius})
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+// sealUnreachable turns every floor cell that can't be reached from x, y into wall
+func (m *Map) sealUnreachable(x int, y int) {
+	reachable := floodFill(m.mapData, x, y)
+	for i := range m.mapData {
+		for j := range m.mapData[i] {
+			if m.mapData[i][j] == 0 && !reachable[i][j] {
+				m.mapData[i][j] = 2
+			}
+		}
+	}
+}
+
+// keepLargestRegion seals every floor area but the largest one, returns the number of floor cells left
+func (m *Map) keepLargestRegion() int {
+	main := largestRegion(m.mapData)
+	for i := range m.mapData {
+		for j := range m.mapData[i] {
+			if m.mapData[i][j] == 0 && !main[i][j] {
+				m.mapData[i][j] = 2
+			}
+		}
+	}
+	return countCells(main)
+}
+
+// floodFill marks the floor cells reachable from x, y through the four neighbouring cells, passing through doors
+func floodFill(mapData [][]int, x int, y int) [][]bool {
+	reachable := make([][]bool, len(mapData))
+	for i := range mapData {
+		reachable[i] = make([]bool, len(mapData[i]))
+	}
+	if mapData[x][y] != 0 {
+		return reachable
+	}
+
+	stack := [][2]int{{x, y}}
+	reachable[x][y] = true
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			i, j := c[0]+d[0], c[1]+d[1]
+			if i < 0 || j < 0 || i >= len(mapData) || j >= len(mapData[i]) {
+				continue
+			}
+			if isFloorOrDoor(mapData[i][j]) && !reachable[i][j] {
+				reachable[i][j] = true
+				stack = append(stack, [2]int{i, j})
+			}
+		}
+	}
+	return reachable
+}
+
+// isConnected reports whether every floor cell of the map is reachable from every other one
+func isConnected(mapData [][]int) bool {
+	var reachable [][]bool
+	for i := range mapData {
+		for j := range mapData[i] {
+			if mapData[i][j] != 0 {
+				continue
+			}
+			if reachable == nil {
+				reachable = floodFill(mapData, i, j)
+			} else if !reachable[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
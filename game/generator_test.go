@@ -0,0 +1,83 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+
+	"gameenv_ai/pathfinding"
+)
+
+// checkConnected fails when the map has floor on its boundary, no floor at all, or floor that can't be reached
+func checkConnected(t *testing.T, name string, m *Map) {
+	t.Helper()
+
+	var floor []pathfinding.Cell
+	for i, row := range m.mapData {
+		for j, cell := range row {
+			if cell != 0 {
+				continue
+			}
+			if i == 0 || j == 0 || i == m.rows-1 || j == m.cols-1 {
+				t.Fatalf("%s: floor on the boundary at %d,%d", name, i, j)
+			}
+			floor = append(floor, pathfinding.Cell{X: i, Y: j})
+		}
+	}
+	if len(floor) == 0 {
+		t.Fatalf("%s: no floor", name)
+	}
+
+	seen := reachable(m.mapData, floor[0])
+	for _, c := range floor {
+		if !seen[c] {
+			t.Fatalf("%s: %v can't be reached from %v", name, c, floor[0])
+		}
+	}
+
+	for _, door := range m.doors {
+		if m.mapData[door[0]][door[1]] != 0 {
+			t.Fatalf("%s: door %v isn't open", name, door)
+		}
+	}
+}
+
+func TestGeneratorsAreConnected(t *testing.T) {
+	generators := map[string]MapGenerator{
+		"dungeon":        DungeonGenerator{},
+		"bsp":            BSPGenerator{},
+		"bsp small":      BSPGenerator{MinLeafSize: 5, CorridorWidth: 2},
+		"maze":           MazeGenerator{},
+		"maze wide":      MazeGenerator{PassageWidth: 3, Loops: 0.2},
+		"caves":          CaveGenerator{},
+		"caves crowded":  CaveGenerator{WallChance: 0.55, Iterations: 3},
+		"maze odd sized": MazeGenerator{PassageWidth: 2},
+	}
+
+	for name, generator := range generators {
+		for seed := int64(1); seed <= 30; seed++ {
+			checkConnected(t, name, generator.Generate(48, 48, seed))
+		}
+		checkConnected(t, name+" 31x57", generator.Generate(31, 57, 1))
+	}
+}
+
+func TestGeneratorsAreDeterministic(t *testing.T) {
+	for _, name := range []MapGeneratorName{GeneratorScatter, GeneratorDungeon, GeneratorBSP, GeneratorMaze, GeneratorCaves} {
+		generator, err := NewMapGenerator(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a, b := generator.Generate(48, 48, 5), generator.Generate(48, 48, 5)
+		if !reflect.DeepEqual(a.mapData, b.mapData) || !reflect.DeepEqual(a.doors, b.doors) {
+			t.Fatalf("%s: the same seed generated different maps", name)
+		}
+		if c := generator.Generate(48, 48, 6); reflect.DeepEqual(a.mapData, c.mapData) {
+			t.Fatalf("%s: different seeds generated the same map", name)
+		}
+	}
+
+	if MapGeneratorName("nope").Validate() == nil {
+		t.Fatal("expected an unknown generator to be rejected")
+	}
+}
@@ -0,0 +1,162 @@
+//go:build !headless
+
+package game
+
+import (
+	"github.com/faiface/pixel/pixelgl"
+	"math"
+)
+
+// Keyboard controls of the runner in the window
+
+func (p *PlayerController) processInput(win *window, dt float64) {
+
+	action := -1
+	if win.Pressed(pixelgl.KeyUp) || win.Pressed(pixelgl.KeyW) {
+		action = 1
+	}
+	if win.Pressed(pixelgl.KeyDown) || win.Pressed(pixelgl.KeyS) {
+		action = 2
+	}
+
+	if win.Pressed(pixelgl.KeyA) {
+		action = 3
+	}
+
+	if win.Pressed(pixelgl.KeyD) {
+		action = 4
+	}
+
+	p.processForwardBackAcceleration(win)
+
+	p.processLeftRightAcceleration(win)
+
+	if win.Pressed(pixelgl.KeyLeft) {
+		p.turnLeft(1.2 * dt)
+		action = 5
+	}
+
+	if win.Pressed(pixelgl.KeyRight) {
+		p.turnRight(1.2 * dt)
+		action = 6
+	}
+
+	if win.JustPressed(pixelgl.KeySpace) || win.JustPressed(pixelgl.KeyE) {
+		p.player.game.useDoor(p.player.view)
+		action = 7
+	}
+
+	//mouseVector := win.MousePosition().Sub(win.MousePreviousPosition())
+	//if mouseVector.X > 0 {
+	//    p.turnRight(mouseVector.X * 0.01)
+	//} else {
+	//    p.turnLeft(mouseVector.X * -0.01)
+	// }
+
+	// Get observation and reward
+
+	if action > 0 {
+
+		p.player.game.advanceClock()
+
+		p.player.view.render()
+
+		reward := p.player.getReward()
+		p1Obs, p1Img := p.player.game.GetPlayer1Observation()
+		episodeLength := p.player.game.currentTick - p.player.game.episodeStartTick
+
+		truncated := episodeLength > p.player.game.secondsToTicks(maxEpisodeSeconds)
+		done := p.player.isDone() || truncated // || touchingWall
+
+		result := RLActionResult{Reward: reward, Observation_Pos: p1Obs, Done: done, Truncated: truncated}
+		result.setObservation(p1Img)
+		p.player.game.recordStep(RLAction(action), result)
+		p.player.game.abortEpisodeLog()
+
+		if done {
+			p.player.game.Reset()
+		}
+
+		// update and save the player's position to p.player.view.old_position every 1000 frames
+		if p.player.game.currentTick-p.player.game.episodeStartTick > p.player.game.secondsToTicks(0.1) {
+			if p.player.game.currentTick-p.player.game.lastPlayer1PositionUpdateTick > p.player.game.secondsToTicks(1) {
+
+				// set is_moving=True if the euclidian distance between old and new positions is greater than 1
+				distTravelled := math.Sqrt(math.Pow(p.player.game.player1Controller.player.old_position.X-p.player.game.player1Controller.player.view.position.X, 2) + math.Pow(p.player.game.player1Controller.player.old_position.Y-p.player.game.player1Controller.player.view.position.Y, 2))
+				if distTravelled < 1e-5 {
+					p.player.game.player1Controller.player.is_moving = false
+				} else {
+					p.player.game.player1Controller.player.is_moving = true
+				}
+				p.player.game.player1Controller.player.old_position = p.player.game.player1Controller.player.view.position
+				p.player.game.lastPlayer1PositionUpdateTick = p.player.game.currentTick
+			}
+		} else {
+			distTravelled := math.Sqrt(math.Pow(p.player.game.player1Controller.player.old_position.X-p.player.game.player1Controller.player.view.position.X, 2) + math.Pow(p.player.game.player1Controller.player.old_position.Y-p.player.game.player1Controller.player.view.position.Y, 2))
+			if distTravelled < 1e-5 {
+				p.player.game.player1Controller.player.is_moving = false
+			} else {
+				p.player.game.player1Controller.player.is_moving = true
+			}
+			if p.player.game.lastPlayer1PositionUpdateTick == 0 {
+				p.player.game.lastPlayer1PositionUpdateTick = p.player.game.currentTick
+			}
+		}
+
+		print(reward, " \r\n")
+	}
+}
+
+func (p *PlayerController) processForwardBackAcceleration(win *window) {
+	accelTriggered := false
+	if win.Pressed(pixelgl.KeyUp) || win.Pressed(pixelgl.KeyW) {
+		p.accelerateForward()
+		accelTriggered = true
+	}
+
+	if win.Pressed(pixelgl.KeyDown) || win.Pressed(pixelgl.KeyS) {
+		p.accelerateBackward()
+		accelTriggered = true
+	}
+
+	if !accelTriggered {
+		if p.player.view.velocity > 0 {
+			p.player.view.velocity += backward_acceleration
+			if p.player.view.velocity < 0 {
+				p.player.view.velocity = 0
+			}
+		} else if p.player.view.velocity < 0 {
+			p.player.view.velocity -= backward_acceleration
+			if p.player.view.velocity > 0 {
+				p.player.view.velocity = 0
+			}
+		}
+	}
+}
+
+func (p *PlayerController) processLeftRightAcceleration(win *window) {
+	accelTriggered := false
+	if win.Pressed(pixelgl.KeyA) {
+		p.accelerateLeft()
+		accelTriggered = true
+	}
+
+	if win.Pressed(pixelgl.KeyD) {
+		p.accelerateRight()
+		accelTriggered = true
+	}
+
+	if !accelTriggered {
+		if p.player.view.horizontalVelocity > 0 {
+			p.player.view.horizontalVelocity += backward_acceleration
+			if p.player.view.horizontalVelocity < 0 {
+				p.player.view.horizontalVelocity = 0
+			}
+		} else if p.player.view.horizontalVelocity < 0 {
+			p.player.view.horizontalVelocity -= backward_acceleration
+			if p.player.view.horizontalVelocity > 0 {
+				p.player.view.horizontalVelocity = 0
+			}
+		}
+	}
+}
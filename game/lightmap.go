@@ -0,0 +1,146 @@
+package game
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// The light map holds how brightly the light sources of a map light every spot of the floor. It is baked at
+// every reset, sampled lightMapResolution times per cell along each axis. Walls occlude the light; doors
+// don't, they are treated as open. The renderer scales walls, floors and ceilings by the light map when
+// GameInstance.Lighting is set.
+
+const (
+	lightMapResolution = 4    // samples per cell along each axis
+	ambientLight       = 0.3  // light level of the spots no light source reaches
+	lightIntensity     = 1.0  // light added right at a light source, falls off to nothing at its radius
+	lightRayStep       = 0.05 // grid cells between the occlusion tests along the ray from a light
+)
+
+// lightMap - light levels between ambientLight and 1, row major by sample
+type lightMap struct {
+	mapData    [][]int
+	rows, cols int // samples along x and y
+	level      []float64
+}
+
+// isOpaque reports whether a cell blocks light, everything but floor and doors does
+func isOpaque(mapData [][]int, x int, y int) bool {
+	if x < 0 || y < 0 || x >= len(mapData) || y >= len(mapData[x]) {
+		return true
+	}
+	return !isFloorOrDoor(mapData[x][y])
+}
+
+// bakeLightMap lights the floor of a map with its light sources
+func bakeLightMap(mapData [][]int, lights []LightSource) *lightMap {
+	l := &lightMap{mapData: mapData, rows: len(mapData) * lightMapResolution}
+	if len(mapData) > 0 {
+		l.cols = len(mapData[0]) * lightMapResolution
+	}
+	l.level = make([]float64, l.rows*l.cols)
+	for i := range l.level {
+		l.level[i] = ambientLight
+	}
+
+	for _, light := range lights {
+		if light.radius <= 0 {
+			continue
+		}
+
+		// only the samples within the radius can be lit
+		minX, maxX := l.sampleRange(light.position.X, light.radius, l.rows)
+		minY, maxY := l.sampleRange(light.position.Y, light.radius, l.cols)
+		for i := minX; i <= maxX; i++ {
+			for j := minY; j <= maxY; j++ {
+				p := l.samplePosition(i, j)
+				if isOpaque(mapData, int(p.X), int(p.Y)) {
+					continue
+				}
+
+				d := p.Sub(light.position).Len()
+				if d >= light.radius || !lightReaches(mapData, light.position, p) {
+					continue
+				}
+
+				falloff := 1 - d/light.radius
+				l.level[i*l.cols+j] = math.Min(l.level[i*l.cols+j]+lightIntensity*falloff*falloff, 1)
+			}
+		}
+	}
+
+	return l
+}
+
+// sampleRange returns the first and last sample within radius of a coordinate
+func (l *lightMap) sampleRange(center float64, radius float64, samples int) (int, int) {
+	first := int(math.Floor((center - radius) * lightMapResolution))
+	last := int(math.Ceil((center + radius) * lightMapResolution))
+	if first < 0 {
+		first = 0
+	}
+	if last > samples-1 {
+		last = samples - 1
+	}
+	return first, last
+}
+
+// samplePosition returns the world position of a sample, samples sit in the middle of their part of the cell
+func (l *lightMap) samplePosition(i int, j int) pixel.Vec {
+	return pixel.V((float64(i)+0.5)/lightMapResolution, (float64(j)+0.5)/lightMapResolution)
+}
+
+// lightReaches reports whether the ray from a light to a spot only crosses open cells. The cell of the light
+// itself doesn't count, so lights placed in a wall still light the cells around it.
+func lightReaches(mapData [][]int, light pixel.Vec, p pixel.Vec) bool {
+	lightX, lightY := int(math.Floor(light.X)), int(math.Floor(light.Y))
+
+	d := p.Sub(light)
+	steps := int(d.Len() / lightRayStep)
+	for s := 1; s < steps; s++ {
+		q := light.Add(d.Scaled(float64(s) / float64(steps)))
+		x, y := int(math.Floor(q.X)), int(math.Floor(q.Y))
+		if (x != lightX || y != lightY) && isOpaque(mapData, x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// at returns the light level of a world position, interpolated between the surrounding samples that lie on
+// open cells so walls don't darken the spots right in front of them
+func (l *lightMap) at(p pixel.Vec) float64 {
+	if l == nil || l.rows == 0 || l.cols == 0 {
+		return 1
+	}
+
+	sx, sy := p.X*lightMapResolution-0.5, p.Y*lightMapResolution-0.5
+	x0, y0 := int(math.Floor(sx)), int(math.Floor(sy))
+	fx, fy := sx-float64(x0), sy-float64(y0)
+
+	var level, weight float64
+	for _, s := range [4]struct {
+		i, j int
+		w    float64
+	}{
+		{x0, y0, (1 - fx) * (1 - fy)},
+		{x0 + 1, y0, fx * (1 - fy)},
+		{x0, y0 + 1, (1 - fx) * fy},
+		{x0 + 1, y0 + 1, fx * fy},
+	} {
+		if s.i < 0 || s.j < 0 || s.i >= l.rows || s.j >= l.cols || s.w == 0 {
+			continue
+		}
+		if isOpaque(l.mapData, s.i/lightMapResolution, s.j/lightMapResolution) {
+			continue
+		}
+		level += l.level[s.i*l.cols+s.j] * s.w
+		weight += s.w
+	}
+
+	if weight == 0 {
+		return ambientLight
+	}
+	return level / weight
+}
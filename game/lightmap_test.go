@@ -0,0 +1,42 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestBakeLightMap(t *testing.T) {
+	f := mapFile{Version: mapFileVersion, Grid: []string{
+		"#########",
+		"#...=...#",
+		"#...=...#",
+		"#...D...#",
+		"#########",
+	}}
+	m, err := f.toMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := bakeLightMap(m.mapData, []LightSource{{pixel.V(3.5, 2.5), 4}})
+
+	// the light falls off with the distance
+	near, far := l.at(pixel.V(3.5, 2.5)), l.at(pixel.V(2.5, 1.5))
+	if near <= far || far <= ambientLight {
+		t.Fatalf("expected the light to fall off, %f at the light and %f a cell away", near, far)
+	}
+
+	// the wall between the rooms casts a shadow, light passes the door
+	if level := l.at(pixel.V(1.5, 5.5)); level > ambientLight+1e-9 {
+		t.Fatalf("light behind the wall is %f", level)
+	}
+	if level := l.at(pixel.V(3.5, 5.5)); level <= ambientLight {
+		t.Fatal("no light through the door")
+	}
+
+	// right in front of a wall the light isn't blended with the dark samples inside it
+	if level := l.at(pixel.V(2.5, 3.99)); level <= ambientLight {
+		t.Fatalf("the wall darkens the spot in front of it to %f", level)
+	}
+}
@@ -0,0 +1,116 @@
+package game
+
+import "sync"
+
+// Role - which of the two agents an action belongs to
+type Role int
+
+const (
+	RoleRunner Role = iota
+	RoleChaser
+)
+
+// lockstep collects one action from each agent before the game is stepped, so a remote
+// runner and a remote chaser always see the same step
+type lockstep struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending [2]*RLAction
+	results [2]RLActionResult
+	round   int
+}
+
+// AttachChaser hands control of the chaser to a remote agent, or gives it back.
+// A runner step that is waiting for the chaser when it detaches is completed on its own.
+func (g *GameInstance) AttachChaser(attached bool) {
+	l := &g.lockstep
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	g.chaserAttached = attached
+	if !attached && l.pending[RoleRunner] != nil {
+		l.complete(g)
+	}
+}
+
+// ChaserAttached reports whether the chaser is driven by a remote agent
+func (g *GameInstance) ChaserAttached() bool {
+	l := &g.lockstep
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return g.chaserAttached
+}
+
+// Step applies an action for one of the agents. Without a remote chaser the runner is stepped
+// right away, otherwise the call blocks until the other agent has sent its action for the step.
+func (g *GameInstance) Step(role Role, action RLAction) RLActionResult {
+	l := &g.lockstep
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if role == RoleRunner && !g.chaserAttached {
+		return g.TakePlayer1Action(action)
+	}
+	g.chaserAttached = g.chaserAttached || role == RoleChaser
+
+	if l.cond == nil {
+		l.cond = sync.NewCond(&l.mu)
+	}
+
+	// an agent that is a step ahead waits for the previous step to finish first
+	for l.pending[role] != nil {
+		l.cond.Wait()
+	}
+	l.pending[role] = &action
+
+	round := l.round
+	if l.pending[RoleRunner] != nil && l.pending[RoleChaser] != nil {
+		l.complete(g)
+	} else {
+		for l.round == round {
+			l.cond.Wait()
+		}
+	}
+	return l.results[role]
+}
+
+func (l *lockstep) complete(g *GameInstance) {
+	if l.pending[RoleChaser] == nil {
+		l.results[RoleRunner] = g.TakePlayer1Action(*l.pending[RoleRunner])
+	} else {
+		l.results[RoleRunner], l.results[RoleChaser] = g.takeActions(*l.pending[RoleRunner], *l.pending[RoleChaser])
+	}
+
+	l.pending = [2]*RLAction{}
+	l.round++
+	if l.cond != nil {
+		l.cond.Broadcast()
+	}
+}
+
+// takeActions moves the chaser, then steps the runner, and returns the results of both agents
+func (g *GameInstance) takeActions(runner RLAction, chaser RLAction) (RLActionResult, RLActionResult) {
+	g.player2Controller.applyAction(chaser)
+	g.loggedChaserAction = &chaser
+
+	runnerResult := g.TakePlayer1Action(runner)
+
+	g.player2Controller.player.view.render()
+
+	p2Obs, p2Img := g.GetPlayer2Observation()
+
+	chaserResult := RLActionResult{Reward: g.player2Controller.player.getReward(), Observation_Pos: p2Obs, Done: runnerResult.Done, Truncated: runnerResult.Truncated, Info: ""}
+	chaserResult.setObservation(p2Img)
+	return runnerResult, chaserResult
+}
+
+// ChaserObservationResult returns the current observation of the chaser without stepping the game
+func (g *GameInstance) ChaserObservationResult() RLActionResult {
+	g.player2Controller.player.view.render()
+
+	p2Obs, p2Img := g.GetPlayer2Observation()
+
+	result := RLActionResult{Reward: 0.0, Observation_Pos: p2Obs, Done: false, Info: "dummy"}
+	result.setObservation(p2Img)
+	return result
+}
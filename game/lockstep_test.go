@@ -0,0 +1,76 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// stepAsync steps the runner on its own goroutine and returns the channel its result arrives on
+func stepAsync(g *GameInstance, action RLAction) chan RLActionResult {
+	done := make(chan RLActionResult, 1)
+	go func() {
+		done <- g.Step(RoleRunner, action)
+	}()
+	return done
+}
+
+// blocked reports whether nothing arrives on the channel for a while
+func blocked(done chan RLActionResult) bool {
+	select {
+	case <-done:
+		return false
+	case <-time.After(50 * time.Millisecond):
+		return true
+	}
+}
+
+func TestLockstep(t *testing.T) {
+	g := newTestGame(t, 64, 48)
+	tick := g.CurrentTick()
+
+	// without a remote chaser the runner steps right away
+	g.Step(RoleRunner, RLActionNone)
+	if g.CurrentTick() != tick+g.ticksPerStep() {
+		t.Fatalf("runner step advanced the clock from %d to %d", tick, g.CurrentTick())
+	}
+
+	// with one, the runner waits for the chaser's action and both see the same step
+	g.AttachChaser(true)
+	tick = g.CurrentTick()
+	done := stepAsync(g, RLActionTurnLeft)
+	if !blocked(done) {
+		t.Fatal("runner step returned before the chaser sent its action")
+	}
+	if g.CurrentTick() != tick {
+		t.Fatal("the clock advanced before the chaser sent its action")
+	}
+
+	chaser := g.player2Controller.player.view.direction
+	chaserResult := g.Step(RoleChaser, RLActionTurnRight)
+	<-done
+	if g.CurrentTick() != tick+g.ticksPerStep() {
+		t.Fatalf("lockstep step advanced the clock from %d to %d", tick, g.CurrentTick())
+	}
+	if g.player2Controller.player.view.direction == chaser {
+		t.Fatal("the chaser's action wasn't applied")
+	}
+	if chaserResult.Width == 0 {
+		t.Fatal("the chaser got no observation")
+	}
+
+	// detaching completes a runner step that is waiting for the chaser on its own
+	tick = g.CurrentTick()
+	done = stepAsync(g, RLActionNone)
+	if !blocked(done) {
+		t.Fatal("runner step returned before the chaser sent its action")
+	}
+	g.AttachChaser(false)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("detaching the chaser didn't release the runner step")
+	}
+	if g.ChaserAttached() || g.CurrentTick() != tick+g.ticksPerStep() {
+		t.Fatalf("after detaching: attached %v, clock advanced from %d to %d", g.ChaserAttached(), tick, g.CurrentTick())
+	}
+}
@@ -0,0 +1,244 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/faiface/pixel"
+)
+
+// Map files are JSON documents:
+//
+//	{
+//	  "Version": 1,
+//	  "Grid": [
+//	    "#####",
+//	    "#..=#",
+//	    "#####"
+//	  ],
+//	  "Lights": [{"X": 1, "Y": 2, "Radius": 5}],
+//	  "Doors": [[1, 3]],
+//	  "RunnerSpawn": {"X": 1.5, "Y": 1.5},
+//	  "ChaserSpawn": {"X": 1.5, "Y": 2.5}
+//	}
+//
+// Grid row i holds the cells mapData[i][0..], one character per cell: '.' (or a space) is floor,
+// '#' the outer boundary wall (1), '-' a wall (2), 'D' a door (3) and '=' a room wall (4).
+// The digits '1' to '9' stand for the cell type of the same value. Every row has the same length
+// and the outermost cells must be walls, neither floor nor doors, so nothing leaves the grid. Lights,
+// doors and spawns are optional; doors must be inside the border and a missing spawn is picked at
+// random from the episode's seed.
+
+const mapFileVersion = 1
+
+var cellChars = map[int]byte{0: '.', 1: '#', 2: '-', 3: 'D', 4: '='}
+
+type mapFile struct {
+	Version     int
+	Grid        []string
+	Lights      []mapFileLight `json:",omitempty"`
+	Doors       [][]int        `json:",omitempty"`
+	RunnerSpawn *mapFilePoint  `json:",omitempty"`
+	ChaserSpawn *mapFilePoint  `json:",omitempty"`
+}
+
+type mapFileLight struct {
+	X, Y   float64
+	Radius float64
+}
+
+type mapFilePoint struct {
+	X, Y float64
+}
+
+// LoadMap reads a map file
+func LoadMap(path string) (*Map, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f mapFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	m, err := f.toMap()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	m.Name = filepath.Base(path)
+	return m, nil
+}
+
+// LoadMapDir reads every .json map file in a directory, sorted by name
+func LoadMapDir(dir string) ([]*Map, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var maps []*Map
+	for _, path := range paths {
+		m, err := LoadMap(path)
+		if err != nil {
+			return nil, err
+		}
+		maps = append(maps, m)
+	}
+
+	if len(maps) == 0 {
+		return nil, errors.New("no map files in " + dir)
+	}
+	return maps, nil
+}
+
+// Save writes the map as a map file
+func (m *Map) Save(path string) error {
+	f := mapFile{Version: mapFileVersion, Doors: m.doors}
+
+	for _, row := range m.mapData {
+		var line strings.Builder
+		for j, cell := range row {
+			c := cellChar(cell)
+			if c == '?' {
+				return fmt.Errorf("cell %d,%d has the value %d, which map files can't hold", len(f.Grid), j, cell)
+			}
+			line.WriteByte(c)
+		}
+		f.Grid = append(f.Grid, line.String())
+	}
+
+	for _, light := range m.lights {
+		f.Lights = append(f.Lights, mapFileLight{X: light.position.X, Y: light.position.Y, Radius: light.radius})
+	}
+
+	if m.runnerSpawn != nil {
+		f.RunnerSpawn = &mapFilePoint{X: m.runnerSpawn.X, Y: m.runnerSpawn.Y}
+	}
+	if m.chaserSpawn != nil {
+		f.ChaserSpawn = &mapFilePoint{X: m.chaserSpawn.X, Y: m.chaserSpawn.Y}
+	}
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+func (f *mapFile) toMap() (*Map, error) {
+	if f.Version != mapFileVersion {
+		return nil, fmt.Errorf("unsupported map file version %d", f.Version)
+	}
+	if len(f.Grid) < 3 || len(f.Grid[0]) < 3 {
+		return nil, errors.New("the grid must be at least 3x3")
+	}
+
+	m := &Map{rows: len(f.Grid), cols: len(f.Grid[0])}
+	for i, line := range f.Grid {
+		if len(line) != m.cols {
+			return nil, fmt.Errorf("grid row %d has %d cells, expected %d", i, len(line), m.cols)
+		}
+
+		row := make([]int, m.cols)
+		for j := 0; j < len(line); j++ {
+			cell, err := cellFromChar(line[j])
+			if err != nil {
+				return nil, fmt.Errorf("grid row %d: %v", i, err)
+			}
+			if (cell == 0 || cell == 3) && m.onBorder(i, j) {
+				return nil, fmt.Errorf("grid cell %d,%d on the border isn't a wall", i, j)
+			}
+			row[j] = cell
+		}
+		m.mapData = append(m.mapData, row)
+	}
+
+	for _, light := range f.Lights {
+		m.lights = append(m.lights, LightSource{position: pixel.V(light.X, light.Y), radius: light.Radius})
+	}
+
+	for _, door := range f.Doors {
+		if len(door) != 2 || !m.inside(door[0], door[1]) || m.onBorder(door[0], door[1]) {
+			return nil, fmt.Errorf("door %v isn't inside the border", door)
+		}
+		m.doors = append(m.doors, []int{door[0], door[1]})
+	}
+
+	var err error
+	if m.runnerSpawn, err = m.spawn(f.RunnerSpawn); err != nil {
+		return nil, fmt.Errorf("runner spawn: %v", err)
+	}
+	if m.chaserSpawn, err = m.spawn(f.ChaserSpawn); err != nil {
+		return nil, fmt.Errorf("chaser spawn: %v", err)
+	}
+
+	return m, nil
+}
+
+// cellChar returns the character of a cell, cell types without one are written as their digit and
+// anything beyond as '?'
+func cellChar(cell int) byte {
+	if c, ok := cellChars[cell]; ok {
+		return c
+	}
+	if cell >= 1 && cell <= 9 {
+		return byte('0' + cell)
+	}
+	return '?'
+}
+
+func cellFromChar(c byte) (int, error) {
+	if c == ' ' {
+		return 0, nil
+	}
+	for cell, char := range cellChars {
+		if char == c {
+			return cell, nil
+		}
+	}
+	if c >= '1' && c <= '9' {
+		return int(c - '0'), nil
+	}
+	return 0, fmt.Errorf("unknown cell %q", c)
+}
+
+func (m *Map) inside(x, y int) bool {
+	return x >= 0 && y >= 0 && x < m.rows && y < m.cols
+}
+
+func (m *Map) onBorder(x, y int) bool {
+	return x == 0 || y == 0 || x == m.rows-1 || y == m.cols-1
+}
+
+func (m *Map) spawn(p *mapFilePoint) (*pixel.Vec, error) {
+	if p == nil {
+		return nil, nil
+	}
+	if !isWalkable(m.mapData, pixel.V(p.X, p.Y)) {
+		return nil, fmt.Errorf("%v,%v is not on a floor cell", p.X, p.Y)
+	}
+	v := pixel.V(p.X, p.Y)
+	return &v, nil
+}
+
+// copyFor returns a copy of a loaded map to play an episode on, the loaded map itself is never changed
+func (m *Map) copyFor(rng *rand.Rand) Map {
+	c := *m
+	c.rng = rng
+	c.mapData = make([][]int, len(m.mapData))
+	for i, row := range m.mapData {
+		c.mapData[i] = append([]int(nil), row...)
+	}
+	c.lights = append([]LightSource(nil), m.lights...)
+	c.doors = append([][]int(nil), m.doors...)
+	return c
+}
@@ -0,0 +1,68 @@
+package game
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMapFileRoundTrip(t *testing.T) {
+	f := mapFile{Version: mapFileVersion, Grid: []string{
+		"#######",
+		"#..-..#",
+		"#..D.2#",
+		"#..=..#",
+		"#######",
+	}, Lights: []mapFileLight{{X: 1.5, Y: 1.5, Radius: 5}}, Doors: [][]int{{2, 3}},
+		RunnerSpawn: &mapFilePoint{X: 1.5, Y: 1.5}, ChaserSpawn: &mapFilePoint{X: 3.5, Y: 5.5}}
+	m, err := f.toMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "level.json")
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Name != "level.json" || !reflect.DeepEqual(loaded.mapData, m.mapData) || !reflect.DeepEqual(loaded.lights, m.lights) ||
+		!reflect.DeepEqual(loaded.doors, m.doors) || *loaded.runnerSpawn != *m.runnerSpawn || *loaded.chaserSpawn != *m.chaserSpawn {
+		t.Fatalf("loaded %+v, saved %+v", loaded, m)
+	}
+}
+
+func TestMapFileRejects(t *testing.T) {
+	valid := []string{
+		"#####",
+		"#...#",
+		"#.D.#",
+		"#####",
+	}
+
+	for _, tc := range []struct {
+		name string
+		f    mapFile
+	}{
+		{"version", mapFile{Version: mapFileVersion + 1, Grid: valid}},
+		{"small grid", mapFile{Version: mapFileVersion, Grid: []string{"###", "###"}}},
+		{"ragged row", mapFile{Version: mapFileVersion, Grid: []string{"#####", "#..#", "#####"}}},
+		{"unknown cell", mapFile{Version: mapFileVersion, Grid: []string{"#####", "#.x.#", "#####"}}},
+		{"floor on the border", mapFile{Version: mapFileVersion, Grid: []string{"##.##", "#...#", "#####"}}},
+		{"door on the border", mapFile{Version: mapFileVersion, Grid: []string{"##D##", "#...#", "#...#", "#####"}}},
+		{"door entry on the border", mapFile{Version: mapFileVersion, Grid: valid, Doors: [][]int{{0, 2}}}},
+		{"door entry outside", mapFile{Version: mapFileVersion, Grid: valid, Doors: [][]int{{7, 2}}}},
+		{"spawn in a wall", mapFile{Version: mapFileVersion, Grid: valid, RunnerSpawn: &mapFilePoint{X: 0.5, Y: 0.5}}},
+	} {
+		if _, err := tc.f.toMap(); err == nil {
+			t.Fatalf("%s: accepted %+v", tc.name, tc.f)
+		}
+	}
+
+	if _, err := (&mapFile{Version: mapFileVersion, Grid: valid, Doors: [][]int{{2, 2}}}).toMap(); err != nil {
+		t.Fatalf("rejected a valid map: %v", err)
+	}
+}
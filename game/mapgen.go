@@ -6,15 +6,22 @@ import (
     "github.com/fogleman/poissondisc"
     "math"
     "math/rand"
+    "strings"
 )
 
 type Map struct {
+    Name    string // file name of a loaded map, empty for generated maps
     lights  []LightSource
     mapData [][]int
     visited [][]bool
     doors   [][]int
     rows    int
     cols    int
+    rng     *rand.Rand
+
+    // Fixed spawn points of a loaded map, nil picks a random spawn
+    runnerSpawn *pixel.Vec
+    chaserSpawn *pixel.Vec
 }
 
 type LightSource struct {
@@ -23,6 +30,11 @@ type LightSource struct {
 }
 
 func (m *Map) GenerateMap() {
+    m.generateScatter(5)
+}
+
+// generateScatter scatters up to numRooms rooms over an open map
+func (m *Map) generateScatter(numRooms int) {
     // Initialize the map with all cells set to the wall cell type.
     m.mapData = make([][]int, m.rows)
     m.visited = make([][]bool, m.rows)
@@ -48,8 +60,8 @@ func (m *Map) GenerateMap() {
 
     // Generate the lights
     for i := 0; i < len(m.lights); i++ {
-        x := rand.Intn(m.rows)
-        y := rand.Intn(m.cols)
+        x := m.rng.Intn(m.rows)
+        y := m.rng.Intn(m.cols)
         m.lights[i] = LightSource{pixel.V(float64(x), float64(y)), 5}
     }
 
@@ -57,7 +69,7 @@ func (m *Map) GenerateMap() {
     //stack := []int{0, 0}
     m.visited[0][0] = true
 
-    m.GenerateRooms(5)
+    m.GenerateRooms(numRooms)
 
     //m.GeneratePaths()
 
@@ -84,21 +96,22 @@ func (m *Map) GenerateMap() {
 }
 
 func (m *Map) GenerateRooms(numRooms int) {
-    maxRoomSize := 8
-    minRoomSize := 6
+    scatterRoomMaxSize := 8
+    scatterRoomMinSize := 6
     outerWallBoundary := 3
 
     // Generate the specified number of rooms.
     for i := 0; i < numRooms; i++ {
 
+        // Give up on the room when the map has no space left for it
         roomGenerationSuccessful := false
-        for !roomGenerationSuccessful {
+        for attempt := 0; !roomGenerationSuccessful && attempt < roomPlacementAttempts; attempt++ {
 
             // Choose a random position and size for the room.
-            x := rand.Intn(m.rows-outerWallBoundary) + outerWallBoundary
-            y := rand.Intn(m.cols-outerWallBoundary) + outerWallBoundary
-            w := rand.Intn(maxRoomSize) + minRoomSize
-            h := rand.Intn(maxRoomSize) + minRoomSize
+            x := m.rng.Intn(m.rows-outerWallBoundary) + outerWallBoundary
+            y := m.rng.Intn(m.cols-outerWallBoundary) + outerWallBoundary
+            w := m.rng.Intn(scatterRoomMaxSize) + scatterRoomMinSize
+            h := m.rng.Intn(scatterRoomMaxSize) + scatterRoomMinSize
 
             // Check if any cell of the room overlaps with a cell of another room.
             overlaps := false
@@ -139,10 +152,10 @@ func (m *Map) GenerateRooms(numRooms int) {
             // Choose a random wall of the room to be the door and set the door cell to the door type.
             doorPlaced := false
             for !doorPlaced {
-                switch rand.Intn(4) {
+                switch m.rng.Intn(4) {
                 case 0:
                     // Top wall.
-                    doorX := rand.Intn(w-2) + x + 1
+                    doorX := m.rng.Intn(w-2) + x + 1
                     if m.mapData[doorX][y] == 4 {
                         m.mapData[doorX][y] = 0
                         m.doors = append(m.doors, []int{doorX, y})
@@ -150,7 +163,7 @@ func (m *Map) GenerateRooms(numRooms int) {
                     }
                 case 1:
                     // Bottom wall.
-                    doorX := rand.Intn(w-2) + x + 1
+                    doorX := m.rng.Intn(w-2) + x + 1
                     if m.mapData[doorX][y+h-1] == 4 {
                         m.mapData[doorX][y+h-1] = 0
                         m.doors = append(m.doors, []int{doorX, y + h - 1})
@@ -158,7 +171,7 @@ func (m *Map) GenerateRooms(numRooms int) {
                     }
                 case 2:
                     // Left wall
-                    doorY := rand.Intn(h-2) + y + 1
+                    doorY := m.rng.Intn(h-2) + y + 1
                     if m.mapData[x][doorY] == 4 {
                         m.mapData[x][doorY] = 0
                         m.doors = append(m.doors, []int{x, doorY})
@@ -166,7 +179,7 @@ func (m *Map) GenerateRooms(numRooms int) {
                     }
                 case 3:
                     // Right wall
-                    doorY := rand.Intn(h-2) + y + 1
+                    doorY := m.rng.Intn(h-2) + y + 1
                     if m.mapData[x+w-1][doorY] == 4 {
                         m.mapData[x+w-1][doorY] = 0
                         m.doors = append(m.doors, []int{x + w - 1, doorY})
@@ -180,24 +193,18 @@ func (m *Map) GenerateRooms(numRooms int) {
     }
 }
 
+// PrintMap prints the cells with the characters of map files, floor as a space
 func (m *Map) PrintMap() {
-    // Print the map.
-    for i := 0; i < m.rows; i++ {
-        for j := 0; j < m.cols; j++ {
-            switch m.mapData[i][j] {
-            case 0:
-                fmt.Print(" ")
-            case 1:
-                fmt.Print("#")
-            case 2:
-                fmt.Print("-")
-            case 3:
-                fmt.Print("D")
-            case 4:
-                fmt.Print("=")
+    for _, row := range m.mapData {
+        var line strings.Builder
+        for _, cell := range row {
+            if cell == 0 {
+                line.WriteByte(' ')
+            } else {
+                line.WriteByte(cellChar(cell))
             }
         }
-        fmt.Println()
+        fmt.Println(line.String())
     }
 }
 
@@ -260,11 +267,11 @@ func addHorizontalWall(m *Map, x, y int) {
 
 func (m *Map) getBestNeighbor(neighbors [][]int, endX, endY int) []int {
     bestNeighbor := neighbors[0]
-    bestScore := blueNoise(bestNeighbor[0], bestNeighbor[1], m.rows, m.cols)
+    bestScore := blueNoise(m.rng, bestNeighbor[0], bestNeighbor[1], m.rows, m.cols)
     hScore := heuristic(bestNeighbor[0], bestNeighbor[1], endX, endY)
     bestScore += hScore
     for _, neighbor := range neighbors[1:] {
-        score := blueNoise(neighbor[0], neighbor[1], m.rows, m.cols) + heuristic(neighbor[0], neighbor[1], endX, endY)
+        score := blueNoise(m.rng, neighbor[0], neighbor[1], m.rows, m.cols) + heuristic(neighbor[0], neighbor[1], endX, endY)
         if score < bestScore {
             bestNeighbor = neighbor
             bestScore = score
@@ -273,7 +280,7 @@ func (m *Map) getBestNeighbor(neighbors [][]int, endX, endY int) []int {
     return bestNeighbor
 }
 
-func blueNoise(x, y, max_x, max_y int) float64 {
+func blueNoise(rng *rand.Rand, x, y, max_x, max_y int) float64 {
     var x0, y0, x1, y1, r float64
     x0 = 0  // bbox min
     y0 = 0  // bbox min
@@ -282,10 +289,10 @@ func blueNoise(x, y, max_x, max_y int) float64 {
     r = 10  // min distance between points
     k := 30 // max attempts to add neighboring point
 
-    points := poissondisc.Sample(x0, y0, x1, y1, r, k, nil)
+    points := poissondisc.Sample(x0, y0, x1, y1, r, k, rng)
 
     // Choose random point
-    randPoint := points[rand.Intn(len(points))]
+    randPoint := points[rng.Intn(len(points))]
 
     return randPoint.X
 }
@@ -0,0 +1,114 @@
+package game
+
+// MazeGenerator - a recursive backtracker maze of passages separated by walls one cell thick. Without loops
+// there is exactly one way between any two places, Loops knocks extra openings into the walls.
+type MazeGenerator struct {
+	PassageWidth int     // width of the passages, in cells
+	Loops        float64 // chance of opening each remaining wall between two neighbouring passages, 0 keeps a perfect maze
+}
+
+// DefaultMazeGenerator is used for every parameter left at zero
+var DefaultMazeGenerator = MazeGenerator{PassageWidth: 1}
+
+func (z MazeGenerator) withDefaults() MazeGenerator {
+	if z.PassageWidth == 0 {
+		z.PassageWidth = DefaultMazeGenerator.PassageWidth
+	}
+	return z
+}
+
+// Generate carves the maze out of solid wall (2)
+func (z MazeGenerator) Generate(rows int, cols int, seed int64) *Map {
+	z = z.withDefaults()
+
+	m := newRockMap(rows, cols, seed)
+
+	// the maze is a grid of nodes, a node is a square of passage with a wall to its neighbours
+	step := z.PassageWidth + 1
+	nodeRows, nodeCols := (rows-2)/step, (cols-2)/step
+	if nodeRows < 1 || nodeCols < 1 {
+		return m
+	}
+
+	visited := make([][]bool, nodeRows)
+	for i := range visited {
+		visited[i] = make([]bool, nodeCols)
+	}
+
+	// the top left cell of a node, the passages start right inside the boundary
+	origin := func(node [2]int) (int, int) {
+		return 1 + node[0]*step, 1 + node[1]*step
+	}
+	open := func(a [2]int, b [2]int) {
+		ax, ay := origin(a)
+		bx, by := origin(b)
+		if bx < ax {
+			ax = bx
+		}
+		if by < ay {
+			ay = by
+		}
+		w, h := z.PassageWidth, z.PassageWidth
+		if a[0] != b[0] {
+			w += step
+		} else if a[1] != b[1] {
+			h += step
+		}
+		for i := ax; i < ax+w; i++ {
+			for j := ay; j < ay+h; j++ {
+				m.mapData[i][j] = 0
+			}
+		}
+	}
+	neighbours := func(node [2]int) [][2]int {
+		var n [][2]int
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			x, y := node[0]+d[0], node[1]+d[1]
+			if x >= 0 && y >= 0 && x < nodeRows && y < nodeCols {
+				n = append(n, [2]int{x, y})
+			}
+		}
+		return n
+	}
+
+	start := [2]int{m.rng.Intn(nodeRows), m.rng.Intn(nodeCols)}
+	visited[start[0]][start[1]] = true
+	open(start, start)
+
+	stack := [][2]int{start}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+
+		var unvisited [][2]int
+		for _, n := range neighbours(node) {
+			if !visited[n[0]][n[1]] {
+				unvisited = append(unvisited, n)
+			}
+		}
+		if len(unvisited) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		next := unvisited[m.rng.Intn(len(unvisited))]
+		visited[next[0]][next[1]] = true
+		open(node, next)
+		stack = append(stack, next)
+	}
+
+	if z.Loops > 0 {
+		for x := 0; x < nodeRows; x++ {
+			for y := 0; y < nodeCols; y++ {
+				for _, n := range [][2]int{{x + 1, y}, {x, y + 1}} {
+					if n[0] < nodeRows && n[1] < nodeCols && m.rng.Float64() < z.Loops {
+						open([2]int{x, y}, n)
+					}
+				}
+			}
+		}
+	}
+
+	m.scatterLights(40, 5)
+
+	return m
+}
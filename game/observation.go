@@ -0,0 +1,145 @@
+package game
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+)
+
+type ObservationEncoding string
+
+const (
+	ObservationJPEG ObservationEncoding = "jpeg"
+	ObservationPNG  ObservationEncoding = "png"
+	ObservationRGB  ObservationEncoding = "rgb"  // raw row-major RGB bytes, 3 per pixel
+	ObservationGray ObservationEncoding = "gray" // raw row-major luminance bytes, 1 per pixel
+)
+
+// ObservationFormat - how rendered frames are encoded before they are handed to an agent
+type ObservationFormat struct {
+	Encoding ObservationEncoding
+	Quality  int  `json:",omitempty"` // jpeg quality 1-100, only used by the jpeg encoding
+	TopDown  bool `json:",omitempty"` // adds the top-down view of the map as extra channels, only with the rgb and gray encodings
+}
+
+// DefaultObservationFormat is used until an agent negotiates a different format
+var DefaultObservationFormat = ObservationFormat{Encoding: ObservationJPEG, Quality: 60}
+
+// EncodedObservation - a rendered frame encoded in the negotiated observation format
+type EncodedObservation struct {
+	Data     []byte
+	Encoding ObservationEncoding
+	Width    int
+	Height   int
+	Channels int
+}
+
+func (f ObservationFormat) Validate() error {
+	switch f.Encoding {
+	case ObservationJPEG:
+		if f.Quality < 1 || f.Quality > 100 {
+			return errors.New("jpeg quality must be between 1 and 100")
+		}
+	case ObservationPNG, ObservationRGB, ObservationGray:
+	default:
+		return errors.New("unknown observation encoding: " + string(f.Encoding))
+	}
+	if f.TopDown && f.Encoding != ObservationRGB && f.Encoding != ObservationGray {
+		return errors.New("the top-down channels need the rgb or gray encoding")
+	}
+	return nil
+}
+
+// SetObservationFormat changes the encoding used for every following observation
+func (g *GameInstance) SetObservationFormat(f ObservationFormat) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	g.observationFormat = &f
+	return nil
+}
+
+// ObservationFormat returns the encoding currently used for observations
+func (g *GameInstance) ObservationFormat() ObservationFormat {
+	if g.observationFormat == nil {
+		return DefaultObservationFormat
+	}
+	return *g.observationFormat
+}
+
+// SetChaserObservationFormat changes the encoding used for the remote chaser's observations
+func (g *GameInstance) SetChaserObservationFormat(f ObservationFormat) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	g.chaserObservationFormat = &f
+	return nil
+}
+
+// ChaserObservationFormat returns the encoding currently used for the chaser's observations
+func (g *GameInstance) ChaserObservationFormat() ObservationFormat {
+	if g.chaserObservationFormat == nil {
+		return DefaultObservationFormat
+	}
+	return *g.chaserObservationFormat
+}
+
+// encodeObservation encodes a rendered frame. With a top-down view, which must have the same size as the frame,
+// the view's channels follow the frame's channels of every pixel.
+func encodeObservation(img *image.RGBA, topDown *image.RGBA, f ObservationFormat) (EncodedObservation, error) {
+	bounds := img.Bounds()
+	obs := EncodedObservation{Encoding: f.Encoding, Width: bounds.Dx(), Height: bounds.Dy(), Channels: 3}
+
+	images := []*image.RGBA{img}
+	if topDown != nil {
+		if topDown.Bounds().Size() != bounds.Size() {
+			return obs, errors.New("the top-down view doesn't match the frame size")
+		}
+		images = append(images, topDown)
+	}
+
+	switch f.Encoding {
+	case ObservationJPEG:
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: f.Quality}); err != nil {
+			return obs, err
+		}
+		obs.Data = buf.Bytes()
+	case ObservationPNG:
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return obs, err
+		}
+		obs.Data = buf.Bytes()
+	case ObservationRGB:
+		obs.Channels = 3 * len(images)
+		obs.Data = make([]byte, 0, obs.Width*obs.Height*obs.Channels)
+		for y := 0; y < obs.Height; y++ {
+			for x := 0; x < obs.Width; x++ {
+				for _, im := range images {
+					c := im.RGBAAt(im.Bounds().Min.X+x, im.Bounds().Min.Y+y)
+					obs.Data = append(obs.Data, c.R, c.G, c.B)
+				}
+			}
+		}
+	case ObservationGray:
+		obs.Channels = len(images)
+		obs.Data = make([]byte, 0, obs.Width*obs.Height*obs.Channels)
+		for y := 0; y < obs.Height; y++ {
+			for x := 0; x < obs.Width; x++ {
+				for _, im := range images {
+					c := im.RGBAAt(im.Bounds().Min.X+x, im.Bounds().Min.Y+y)
+					// same weights as color.GrayModel
+					lum := (19595*uint32(c.R) + 38470*uint32(c.G) + 7471*uint32(c.B) + 1<<15) >> 16
+					obs.Data = append(obs.Data, uint8(lum))
+				}
+			}
+		}
+	default:
+		return obs, errors.New("unknown observation encoding: " + string(f.Encoding))
+	}
+
+	return obs, nil
+}
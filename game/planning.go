@@ -1,117 +1,146 @@
 package game
 
 import (
+	"gameenv_ai/pathfinding"
 	"github.com/faiface/pixel"
 	"math"
 )
 
-type point struct {
-	x, y int
-	//parent *point
-	path []pixel.Vec
-}
+// Paths are planned on the 8 connected grid, without squeezing diagonally past wall corners. They lead
+// through doors, which are opened on the way.
+var pathOptions = pathfinding.Options{Connectivity: pathfinding.EightConnected, Walkable: isFloorOrDoor}
 
-// implement == and != operators on point
-func (p *point) Equal(p2 *point) bool {
-	return p.x == p2.x && p.y == p2.y
+func isFloorOrDoor(cell int) bool {
+	return cell == 0 || cell == 3
 }
 
-// THIS DOESN'T WORK GRUMBLE GRUMBLE FIX ME
-
-func (g *GameInstance) autoPlan(controller *PlayerController, controller2 *EnemyController, dt float64) {
+// Tolerances used when steering along a path
+const (
+	headingTolerance = 0.2 // radians
+	waypointRadius   = 0.5 // grid cells
+)
 
-	if g.planPath == nil {
-		g.planPath = make([]pixel.Vec, 0)
-		startPos := controller.player.getPosition()
-		destination := controller2.player.getPosition()
+// findPath returns the waypoints of the shortest walkable path between two world positions
+func (g *GameInstance) findPath(start pixel.Vec, destination pixel.Vec) ([]pixel.Vec, bool) {
+	return pathfinding.FindPath(g.mapData, start, destination, pathOptions)
+}
 
-		g.planPath = plotPathToDestination(g.mapData, startPos, destination)
-	}
+// steerTowards turns until the target is within the heading tolerance, then moves forward
+func steerTowards(position pixel.Vec, direction pixel.Vec, target pixel.Vec) RLAction {
+	toTarget := target.Sub(position)
 
-	if len(g.planPath) == 0 {
-		return
+	angle := math.Atan2(direction.Cross(toTarget), direction.Dot(toTarget))
+	if angle > headingTolerance {
+		return RLActionTurnLeft
 	}
-	// get the next point in the path
-	next := g.planPath[0]
-	// check if the player has reached the next point in the path
-	nPos := next.Sub(controller.player.getPosition()).Len()
-	if nPos < 2 {
-		g.planPath = g.planPath[1:]
-		return
+	if angle < -headingTolerance {
+		return RLActionTurnRight
 	}
-	// calculate the direction vector towards the next point in the path
-	///direction := next.Sub(controller.player.getPosition()).Normal()
 
-	// rotate the player towards the direction vector
-	targetVec := next.Sub(controller.player.getPosition())
-	// calculate the angle between the direction vector and the target vector
-	angle := math.Acos(controller.player.getRotation().Dot(targetVec) / (controller.player.getRotation().Len() * targetVec.Len()))
+	return RLActionMoveForward
+}
 
-	// convert radians to degrees
-	angle = angle * 180 / math.Pi
+// dropReachedWaypoints removes the waypoints at the front of the path that are within reach of position
+func dropReachedWaypoints(path []pixel.Vec, position pixel.Vec) []pixel.Vec {
+	for len(path) > 0 && position.Sub(path[0]).Len() < waypointRadius {
+		path = path[1:]
+	}
+	return path
+}
 
-	print(angle, "\r\n")
+// Autopilot - expert policy that walks the runner along the shortest path to the chaser.
+// It turns until the next waypoint is within the heading tolerance, then moves forward, and
+// plans again when the chaser has moved away from the end of the path or the runner is stuck.
+// Asking for the next action only queries it: the plan is kept and the stuck detection updated by
+// the steps the runner actually takes.
+type Autopilot struct {
+	game *GameInstance
+
+	path       []pixel.Vec
+	goal       pixel.Vec // where the chaser was when the path was planned
+	stuckSteps int
+}
 
-	// rotate the player towards the next point in the path
-	if angle > 1 {
-		controller.turnLeft(0.005)
-		return
+const (
+	autopilotReplanDistance = 1.0 // grid cells the chaser may move before the path is planned again
+	autopilotStuckSteps     = 3   // forward steps without moving before the runner backs off
+)
+
+// autopilot returns the runner's autopilot, creating it on first use
+func (g *GameInstance) autopilot() *Autopilot {
+	if g.runnerAutopilot == nil {
+		g.runnerAutopilot = &Autopilot{game: g}
 	}
+	return g.runnerAutopilot
+}
 
-	if angle < 0 {
-		controller.turnRight(0.005)
-		return
+// ExpertAction returns the action the autopilot would take for the runner from its current pose
+func (g *GameInstance) ExpertAction() RLAction {
+	return g.autopilot().NextAction()
+}
+
+// reset forgets the plan of the previous episode
+func (a *Autopilot) reset() {
+	a.path = nil
+	a.stuckSteps = 0
+}
+
+// NextAction returns the next action along the path to the chaser
+func (a *Autopilot) NextAction() RLAction {
+	// back off whatever blocks the way, the path is planned again from there
+	if a.stuckSteps >= autopilotStuckSteps {
+		return RLActionMoveBackward
 	}
 
-	controller.accelerateForward()
+	path, _ := a.plan()
+	if len(path) == 0 {
+		return RLActionNone
+	}
 
+	runner := a.game.player1Controller.player
+	return a.game.throughDoors(runner.view, steerTowards(runner.getPosition(), runner.getRotation(), path[0]))
 }
 
-func plotPathToDestination(mapData [][]int, startPos pixel.Vec, destination pixel.Vec) []pixel.Vec {
-	// A* pathfinding algorithm
-	start := point{int(startPos.X), int(startPos.Y), []pixel.Vec{startPos}}
-	dest := point{int(destination.X), int(destination.Y), nil}
-	openSet := map[*point]bool{&start: true}
-	closedSet := map[*point]bool{}
-	for len(openSet) > 0 {
-		// find the point in openSet with the smallest distance to the destination
-		current := point{-1, -1, nil}
-		for p := range openSet {
-			if current.x == -1 || p.distToDestination(pixel.Vec{X: float64(dest.x), Y: float64(dest.y)}) < current.distToDestination(pixel.V(float64(dest.x), float64(dest.y))) {
-				current = *p
-			}
-		}
+// plan returns the path to follow from the runner's pose and where the chaser was when it was planned:
+// the kept path without the waypoints already reached, or a new one when the chaser has moved away
+func (a *Autopilot) plan() ([]pixel.Vec, pixel.Vec) {
+	position := a.game.player1Controller.player.getPosition()
+	chaser := a.game.player2Controller.player.getPosition()
 
-		if current.Equal(&dest) {
-			path := current.path
-			path = append(path, pixel.Vec{X: float64(current.x), Y: float64(current.y)})
-			return path
-		}
-		//delete(openSet, &current)
-		closedSet[&current] = true
-		current.addNeighbors(mapData, openSet, closedSet)
+	path, goal := a.path, a.goal
+	if len(path) == 0 || chaser.Sub(goal).Len() > autopilotReplanDistance {
+		path, _ = a.game.findPath(position, chaser)
+		goal = chaser
 	}
-	return []pixel.Vec{}
+	return dropReachedWaypoints(path, position), goal
 }
 
-func (p *point) addNeighbors(mapData [][]int, openSet, closedSet map[*point]bool) {
-	// check all 8 possible neighbors, including diagonals
-	neighbors := []*point{&point{p.x - 1, p.y, nil}, &point{p.x + 1, p.y, nil}, &point{p.x, p.y - 1, nil}, &point{p.x, p.y + 1, nil},
-		&point{p.x - 1, p.y - 1, nil}, &point{p.x - 1, p.y + 1, nil}, &point{p.x + 1, p.y - 1, nil}, &point{p.x + 1, p.y + 1, nil}}
-	for _, neighbor := range neighbors {
-		if neighbor.x < 0 || neighbor.y < 0 || neighbor.x >= len(mapData) || neighbor.y >= len(mapData[0]) ||
-			mapData[neighbor.x][neighbor.y] != 0 || closedSet[neighbor] {
-			continue
-		}
-		if _, ok := closedSet[neighbor]; !ok && mapData[neighbor.x][neighbor.y] == 0 {
-			neighbor.path = append(p.path, pixel.Vec{X: float64(p.x), Y: float64(p.y)})
-			openSet[neighbor] = true
+// observe counts the forward steps the runner took from a position without moving, and drops the path
+// once it has backed off
+func (a *Autopilot) observe(action RLAction, from pixel.Vec) {
+	if a.stuckSteps >= autopilotStuckSteps && action == RLActionMoveBackward {
+		a.stuckSteps = 0
+		a.path = nil
+		return
+	}
+
+	if action == RLActionMoveForward {
+		if a.game.player1Controller.player.getPosition().Sub(from).Len() < 0.01 {
+			a.stuckSteps++
+		} else {
+			a.stuckSteps = 0
 		}
 	}
 }
 
-func (p *point) distToDestination(destination pixel.Vec) float64 {
-	// Manhattan distance heuristic
-	dist := math.Abs(float64(p.x)-destination.X) + math.Abs(float64(p.y)-destination.Y)
-	return dist
+// stepAutopilot drives the runner with the autopilot for one step, in place of keyboard input
+func (g *GameInstance) stepAutopilot() {
+	// the runner follows the plan, it is kept for the next step
+	a := g.autopilot()
+	a.path, a.goal = a.plan()
+	result := g.TakePlayer1Action(a.NextAction())
+
+	if result.Done {
+		g.Reset()
+	}
 }
@@ -0,0 +1,90 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+
+	"gameenv_ai/pathfinding"
+)
+
+// reachable flood fills the floor from a cell
+func reachable(mapData [][]int, from pathfinding.Cell) map[pathfinding.Cell]bool {
+	seen := map[pathfinding.Cell]bool{from: true}
+	queue := []pathfinding.Cell{from}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, n := range []pathfinding.Cell{{X: c.X + 1, Y: c.Y}, {X: c.X - 1, Y: c.Y}, {X: c.X, Y: c.Y + 1}, {X: c.X, Y: c.Y - 1}} {
+			if !seen[n] && mapData[n.X][n.Y] == 0 {
+				seen[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	return seen
+}
+
+func TestFindPathOnGeneratedMaps(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		m := Map{rows: 48, cols: 48, rng: rng}
+		m.GenerateMap()
+		g := &GameInstance{mapData: m.mapData}
+
+		for i := 0; i < 10; i++ {
+			start := getRandomStartPosition(&g.mapData, rng)
+			destination := getRandomStartPosition(&g.mapData, rng)
+
+			path, found := g.findPath(start, destination)
+			connected := reachable(g.mapData, pathfinding.CellOf(start))[pathfinding.CellOf(destination)]
+			if found != connected {
+				t.Fatalf("seed %d: path found = %v, but flood fill connected = %v", seed, found, connected)
+			}
+			if !found {
+				continue
+			}
+
+			if path[len(path)-1] != destination {
+				t.Fatalf("seed %d: path ends at %v, expected %v", seed, path[len(path)-1], destination)
+			}
+
+			previous := pathfinding.CellOf(start)
+			for _, p := range path {
+				c := pathfinding.CellOf(p)
+				if g.mapData[c.X][c.Y] != 0 {
+					t.Fatalf("seed %d: waypoint %v is inside a wall", seed, p)
+				}
+				if dx, dy := c.X-previous.X, c.Y-previous.Y; dx*dx > 1 || dy*dy > 1 {
+					t.Fatalf("seed %d: waypoints %v and %v aren't neighbours", seed, previous, c)
+				}
+				previous = c
+			}
+		}
+	}
+}
+
+func TestExpertActionIsAQuery(t *testing.T) {
+	g := newTestGame(t, 64, 48)
+
+	want := g.ExpertAction()
+	for i := 0; i < 10; i++ {
+		if a := g.ExpertAction(); a != want {
+			t.Fatalf("call %d returned %d at the same pose, the first returned %d", i, a, want)
+		}
+	}
+	if g.autopilot().path != nil {
+		t.Fatal("asking for the expert action kept a plan")
+	}
+
+	// a runner stuck on a wall backs off until it has taken the step back
+	g.autopilot().stuckSteps = autopilotStuckSteps
+	for i := 0; i < 10; i++ {
+		if a := g.ExpertAction(); a != RLActionMoveBackward {
+			t.Fatalf("stuck runner got %d, expected to back off", a)
+		}
+	}
+	g.TakePlayer1Action(RLActionMoveBackward)
+	if g.autopilot().stuckSteps != 0 {
+		t.Fatal("backing off didn't clear the stuck steps")
+	}
+}
@@ -78,15 +78,18 @@ func (p *Player) getReward() float32 {
 
 func (p *Player) getIntensityValuesAroundPlayer() [][]float64 {
 	enemy := p.game.player2Controller.player
-	intensity := getIntensityValues(p.game.mapData, enemy.getPosition())
+	return intensityAround(p.game.mapData, p.getPosition(), enemy.getPosition())
+}
+
+// intensityAround returns the sound intensity of a source in a 1 grid cell radius around the listener
+func intensityAround(mapData [][]int, listener pixel.Vec, source pixel.Vec) [][]float64 {
+	intensity := getIntensityValues(mapData, source)
 
 	// Filter intensity values to only include values in a 1 grid cell radius around player's position
 	filteredIntensity := [][]float64{}
-	playerPos := p.getPosition()
-	mapData := p.game.mapData
-	for i := playerPos.X - 1; i <= playerPos.X+1; i++ {
+	for i := listener.X - 1; i <= listener.X+1; i++ {
 		row := []float64{}
-		for j := playerPos.Y - 1; j <= playerPos.Y+1; j++ {
+		for j := listener.Y - 1; j <= listener.Y+1; j++ {
 			if i >= 0 && int(i) < len(mapData) && j >= 0 && int(j) < len(mapData[0]) {
 				row = append(row, intensity[int(i)][int(j)])
 			}
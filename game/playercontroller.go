@@ -1,7 +1,6 @@
 package game
 
 import (
-	"github.com/faiface/pixel/pixelgl"
 	"math"
 )
 
@@ -15,149 +14,6 @@ const backward_acceleration = -0.003
 const max_velocity = 0.1
 const min_velocity = -0.1
 
-func (p *PlayerController) processInput(win *pixelgl.Window, dt float64) {
-
-	action := -1
-	if win.Pressed(pixelgl.KeyUp) || win.Pressed(pixelgl.KeyW) {
-		action = 1
-	}
-	if win.Pressed(pixelgl.KeyDown) || win.Pressed(pixelgl.KeyS) {
-		action = 2
-	}
-
-	if win.Pressed(pixelgl.KeyA) {
-		action = 3
-	}
-
-	if win.Pressed(pixelgl.KeyD) {
-		action = 4
-	}
-
-	p.processForwardBackAcceleration(win)
-
-	p.processLeftRightAcceleration(win)
-
-	if win.Pressed(pixelgl.KeyLeft) {
-		p.turnLeft(1.2 * dt)
-		action = 5
-	}
-
-	if win.Pressed(pixelgl.KeyRight) {
-		p.turnRight(1.2 * dt)
-		action = 6
-	}
-
-	//mouseVector := win.MousePosition().Sub(win.MousePreviousPosition())
-	//if mouseVector.X > 0 {
-	//    p.turnRight(mouseVector.X * 0.01)
-	//} else {
-	//    p.turnLeft(mouseVector.X * -0.01)
-	// }
-
-	// Get observation and reward
-
-	if action > 0 {
-
-		p.player.view.render()
-
-		reward := p.player.getReward()
-		//p1Obs, _ := p.player.game.GetPlayer1Observation()
-		episodeLength := p.player.game.currentTick - p.player.game.episodeStartTick
-
-		done := p.player.isDone() || episodeLength > maxEpisodeLength // || touchingWall
-
-		if done {
-			p.player.game.Reset()
-		}
-
-		if action > 0 {
-			p.player.game.recordPlayerSet(action, reward, done)
-		}
-
-		// update and save the player's position to p.player.view.old_position every 1000 frames
-		if p.player.game.currentTick-p.player.game.episodeStartTick > 100 {
-			if p.player.game.currentTick-p.player.game.lastPlayer1PositionUpdateTick > (1 * 1000) {
-
-				// set is_moving=True if the euclidian distance between old and new positions is greater than 1
-				distTravelled := math.Sqrt(math.Pow(p.player.game.player1Controller.player.old_position.X-p.player.game.player1Controller.player.view.position.X, 2) + math.Pow(p.player.game.player1Controller.player.old_position.Y-p.player.game.player1Controller.player.view.position.Y, 2))
-				if distTravelled < 1e-5 {
-					p.player.game.player1Controller.player.is_moving = false
-				} else {
-					p.player.game.player1Controller.player.is_moving = true
-				}
-				p.player.game.player1Controller.player.old_position = p.player.game.player1Controller.player.view.position
-				p.player.game.lastPlayer1PositionUpdateTick = p.player.game.currentTick
-			}
-		} else {
-			distTravelled := math.Sqrt(math.Pow(p.player.game.player1Controller.player.old_position.X-p.player.game.player1Controller.player.view.position.X, 2) + math.Pow(p.player.game.player1Controller.player.old_position.Y-p.player.game.player1Controller.player.view.position.Y, 2))
-			if distTravelled < 1e-5 {
-				p.player.game.player1Controller.player.is_moving = false
-			} else {
-				p.player.game.player1Controller.player.is_moving = true
-			}
-			if p.player.game.lastPlayer1PositionUpdateTick == 0 {
-				p.player.game.lastPlayer1PositionUpdateTick = p.player.game.currentTick
-			}
-		}
-
-		print(reward, " \r\n")
-	}
-}
-
-func (p *PlayerController) processForwardBackAcceleration(win *pixelgl.Window) {
-	accelTriggered := false
-	if win.Pressed(pixelgl.KeyUp) || win.Pressed(pixelgl.KeyW) {
-		p.accelerateForward()
-		accelTriggered = true
-	}
-
-	if win.Pressed(pixelgl.KeyDown) || win.Pressed(pixelgl.KeyS) {
-		p.accelerateBackward()
-		accelTriggered = true
-	}
-
-	if !accelTriggered {
-		if p.player.view.velocity > 0 {
-			p.player.view.velocity += backward_acceleration
-			if p.player.view.velocity < 0 {
-				p.player.view.velocity = 0
-			}
-		} else if p.player.view.velocity < 0 {
-			p.player.view.velocity -= backward_acceleration
-			if p.player.view.velocity > 0 {
-				p.player.view.velocity = 0
-			}
-		}
-	}
-}
-
-func (p *PlayerController) processLeftRightAcceleration(win *pixelgl.Window) {
-	accelTriggered := false
-	if win.Pressed(pixelgl.KeyA) {
-		p.accelerateLeft()
-		accelTriggered = true
-	}
-
-	if win.Pressed(pixelgl.KeyD) {
-		p.accelerateRight()
-		accelTriggered = true
-	}
-
-	if !accelTriggered {
-		if p.player.view.horizontalVelocity > 0 {
-			p.player.view.horizontalVelocity += backward_acceleration
-			if p.player.view.horizontalVelocity < 0 {
-				p.player.view.horizontalVelocity = 0
-			}
-		} else if p.player.view.horizontalVelocity < 0 {
-			p.player.view.horizontalVelocity -= backward_acceleration
-			if p.player.view.horizontalVelocity > 0 {
-				p.player.view.horizontalVelocity = 0
-			}
-		}
-	}
-}
-
 func (p *PlayerController) moveForward(s float64) {
 	mapData := p.player.game.mapData
 	_, ok := interface{}(mapData).([][]int)
@@ -165,11 +21,11 @@ func (p *PlayerController) moveForward(s float64) {
 	if ok {
 
 		if p.player.view.distanceToWall > 0.3 {
-			if mapData[int(p.player.view.position.X+p.player.view.direction.X*s)][int(p.player.view.position.Y)] == 0 {
+			if p.player.game.isOpenCell(int(p.player.view.position.X+p.player.view.direction.X*s), int(p.player.view.position.Y)) {
 				p.player.view.position.X += p.player.view.direction.X * s
 			}
 
-			if mapData[int(p.player.view.position.X)][int(p.player.view.position.Y+p.player.view.direction.Y*s)] == 0 {
+			if p.player.game.isOpenCell(int(p.player.view.position.X), int(p.player.view.position.Y+p.player.view.direction.Y*s)) {
 				p.player.view.position.Y += p.player.view.direction.Y * s
 			}
 		}
@@ -222,11 +78,11 @@ func (p *PlayerController) moveLeft(s float64) {
 	_, ok := interface{}(mapData).([][]int)
 
 	if ok {
-		if mapData[int(p.player.view.position.X-p.player.view.plane.X*s)][int(p.player.view.position.Y)] == 0 {
+		if p.player.game.isOpenCell(int(p.player.view.position.X-p.player.view.plane.X*s), int(p.player.view.position.Y)) {
 			p.player.view.position.X -= p.player.view.plane.X * s
 		}
 
-		if mapData[int(p.player.view.position.X)][int(p.player.view.position.Y-p.player.view.plane.Y*s)] == 0 {
+		if p.player.game.isOpenCell(int(p.player.view.position.X), int(p.player.view.position.Y-p.player.view.plane.Y*s)) {
 			p.player.view.position.Y -= p.player.view.plane.Y * s
 		}
 	}
@@ -241,7 +97,7 @@ func (p *PlayerController) moveBackwards(s float64) {
 		newX := int(p.player.view.position.X - p.player.view.direction.X*s)
 		if newX >= 0 && newX < len(mapData) {
 			// Check if new position is empty space
-			if mapData[newX][int(p.player.view.position.Y)] == 0 {
+			if p.player.game.isOpenCell(newX, int(p.player.view.position.Y)) {
 				p.player.view.position.X -= p.player.view.direction.X * s
 			}
 		}
@@ -250,7 +106,7 @@ func (p *PlayerController) moveBackwards(s float64) {
 		newY := int(p.player.view.position.Y - p.player.view.direction.Y*s)
 		if newY >= 0 && newY < len(mapData[0]) {
 			// Check if new position is empty space
-			if mapData[int(p.player.view.position.X)][newY] == 0 {
+			if p.player.game.isOpenCell(int(p.player.view.position.X), newY) {
 				p.player.view.position.Y -= p.player.view.direction.Y * s
 			}
 		}
@@ -262,11 +118,11 @@ func (p *PlayerController) moveRight(s float64) {
 	_, ok := interface{}(mapData).([][]int)
 
 	if ok {
-		if mapData[int(p.player.view.position.X+p.player.view.plane.X*s)][int(p.player.view.position.Y)] == 0 {
+		if p.player.game.isOpenCell(int(p.player.view.position.X+p.player.view.plane.X*s), int(p.player.view.position.Y)) {
 			p.player.view.position.X += p.player.view.plane.X * s
 		}
 
-		if mapData[int(p.player.view.position.X)][int(p.player.view.position.Y+p.player.view.plane.Y*s)] == 0 {
+		if p.player.game.isOpenCell(int(p.player.view.position.X), int(p.player.view.position.Y+p.player.view.plane.Y*s)) {
 			p.player.view.position.Y += p.player.view.plane.Y * s
 		}
 	}
@@ -0,0 +1,219 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Trajectories are written as json lines, one TrajectoryStep per line, into numbered chunk
+// files in the output directory: chunk-000000.jsonl, chunk-000001.jsonl, ...
+// A chunk always holds whole episodes.
+
+const defaultStepsPerChunk = 10000
+
+// RecorderConfig - where and how trajectories are written
+type RecorderConfig struct {
+	Dir           string // output directory, created when it doesn't exist
+	StepsPerChunk int    // a new chunk is started at the first episode end after this many steps, 0 uses 10000
+	MaxChunks     int    // the oldest chunks are deleted when there are more than this, 0 keeps every chunk
+}
+
+// Pose - position, view direction and camera plane of a player
+type Pose struct {
+	X, Y           float64
+	DirX, DirY     float64
+	PlaneX, PlaneY float64
+}
+
+// TrajectoryStep - one recorded step of an episode. The observation is the one the action was taken
+// from, the reset frame for the first step, and the reward, flags and poses are what the action led to.
+type TrajectoryStep struct {
+	Episode         int   // episode counter of the instance
+	Seed            int64 // the episode's seed, replays the same map and spawns
+	Step            int   // index of the step within the episode, the first step is 0
+	Action          RLAction
+	Reward          float32
+	Done            bool
+	Truncated       bool
+	Observation     []byte // image as encoded for the agent, before the action was applied
+	Encoding        ObservationEncoding
+	Width           int
+	Height          int
+	Channels        int
+	Observation_Pos []float64
+	Runner          Pose // pose after the action was applied
+	Chaser          Pose
+}
+
+// Recorder - writes trajectories of every controller driving the runner into a chunked dataset
+type Recorder struct {
+	config RecorderConfig
+
+	mu           sync.Mutex
+	file         *os.File
+	writer       *bufio.Writer
+	nextChunk    int
+	stepsInChunk int
+	chunks       []string // chunk files that are kept, oldest first
+}
+
+// NewRecorder creates the output directory and continues numbering after the chunks already in it
+func NewRecorder(config RecorderConfig) (*Recorder, error) {
+	if config.StepsPerChunk <= 0 {
+		config.StepsPerChunk = defaultStepsPerChunk
+	}
+
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	existing, err := filepath.Glob(filepath.Join(config.Dir, "chunk-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(existing)
+
+	r := &Recorder{config: config, chunks: existing}
+	for _, chunk := range existing {
+		var n int
+		if _, err := fmt.Sscanf(filepath.Base(chunk), "chunk-%06d.jsonl", &n); err == nil && n >= r.nextChunk {
+			r.nextChunk = n + 1
+		}
+	}
+
+	return r, nil
+}
+
+// Record appends a step, and rotates to a new chunk once the current one is full and the episode is over
+func (r *Recorder) Record(step TrajectoryStep) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.openChunk(); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(step)
+	if err != nil {
+		return err
+	}
+	if _, err := r.writer.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	r.stepsInChunk++
+
+	if !step.Done {
+		return nil
+	}
+
+	// flush whole episodes, so a chunk never ends in the middle of one
+	if err := r.writer.Flush(); err != nil {
+		return err
+	}
+	if r.stepsInChunk >= r.config.StepsPerChunk {
+		return r.closeChunk()
+	}
+	return nil
+}
+
+// Close flushes and closes the current chunk
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	return r.closeChunk()
+}
+
+func (r *Recorder) openChunk() error {
+	name := filepath.Join(r.config.Dir, fmt.Sprintf("chunk-%06d.jsonl", r.nextChunk))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	r.nextChunk++
+	r.file = f
+	r.writer = bufio.NewWriter(f)
+	r.stepsInChunk = 0
+	r.chunks = append(r.chunks, name)
+
+	// drop the oldest chunks beyond the limit
+	for r.config.MaxChunks > 0 && len(r.chunks) > r.config.MaxChunks {
+		if err := os.Remove(r.chunks[0]); err != nil && !os.IsNotExist(err) {
+			log.Println("Error removing old chunk: ", err)
+		}
+		r.chunks = r.chunks[1:]
+	}
+	return nil
+}
+
+func (r *Recorder) closeChunk() error {
+	err := r.writer.Flush()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file = nil
+	r.writer = nil
+	return err
+}
+
+func poseOf(view *RenderView) Pose {
+	return Pose{X: view.position.X, Y: view.position.Y, DirX: view.direction.X, DirY: view.direction.Y, PlaneX: view.plane.X, PlaneY: view.plane.Y}
+}
+
+// beginRecordedEpisode keeps the reset frame, the first step of the episode is taken from it
+func (g *GameInstance) beginRecordedEpisode() {
+	g.recordedFrom = nil
+	if g.Recorder != nil {
+		from := g.ObservationResult()
+		g.recordedFrom = &from
+	}
+}
+
+// recordStep hands a runner step to the recorder, whichever controller took it. The step's result is
+// kept as the observation the next step is taken from.
+func (g *GameInstance) recordStep(action RLAction, result RLActionResult) {
+	if g.Recorder == nil {
+		return
+	}
+
+	// a recorder attached in the middle of an episode starts with the step after it first saw a frame
+	from := g.recordedFrom
+	g.recordedFrom = &result
+	if from == nil {
+		return
+	}
+
+	step := TrajectoryStep{
+		Episode:         g.episodeCount,
+		Seed:            g.episodeSeed,
+		Step:            g.episodeSteps - 1,
+		Action:          action,
+		Reward:          result.Reward,
+		Done:            result.Done,
+		Truncated:       result.Truncated,
+		Observation:     from.Observation,
+		Encoding:        from.Encoding,
+		Width:           from.Width,
+		Height:          from.Height,
+		Channels:        from.Channels,
+		Observation_Pos: from.Observation_Pos,
+		Runner:          poseOf(g.player1Controller.player.view),
+		Chaser:          poseOf(g.player2Controller.player.view),
+	}
+
+	if err := g.Recorder.Record(step); err != nil {
+		log.Println("Error recording step: ", err)
+	}
+}
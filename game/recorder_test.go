@@ -0,0 +1,64 @@
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecorderPairsActionsWithTheirObservation(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewRecorder(RecorderConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := newTestGame(t, 64, 48)
+	g.Recorder = recorder
+	g.Reset()
+
+	// the observation every action is taken from: the reset frame, then the result of the previous step
+	observations := [][]byte{g.ObservationResult().Observation}
+	actions := []RLAction{RLActionTurnLeft, RLActionMoveForward, RLActionTurnRight, RLActionMoveBackward}
+	for _, action := range actions {
+		observations = append(observations, g.TakePlayer1Action(action).Observation)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "chunk-000000.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var steps []TrajectoryStep
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		var step TrajectoryStep
+		if err := json.Unmarshal(scanner.Bytes(), &step); err != nil {
+			t.Fatal(err)
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) != len(actions) {
+		t.Fatalf("recorded %d steps, expected %d", len(steps), len(actions))
+	}
+
+	for i, step := range steps {
+		if step.Step != i || step.Action != actions[i] {
+			t.Fatalf("step %d recorded as step %d with action %d", i, step.Step, step.Action)
+		}
+		if !bytes.Equal(step.Observation, observations[i]) {
+			t.Fatalf("step %d isn't recorded with the observation its action was taken from", i)
+		}
+	}
+	if bytes.Equal(observations[0], observations[1]) {
+		t.Fatal("turning didn't change the observation, the test can't tell the frames apart")
+	}
+}
@@ -1,6 +1,5 @@
 package game
 
-import "C"
 import (
 	"fmt"
 	"github.com/faiface/pixel"
@@ -10,6 +9,7 @@ import (
 	"image"
 	"image/color"
 	"math"
+	"runtime"
 	"sync"
 )
 
@@ -34,6 +34,8 @@ type RenderView struct {
 	distanceToWall             float64 // Calculated after a render cycle
 	zBuffer                    [][]float64
 	isOtherPlayerSpriteVisible bool
+
+	serial bool // cast the columns on the calling goroutine instead of the worker pool
 }
 
 type RenderListener struct {
@@ -64,222 +66,301 @@ func (c *RenderView) render() *image.RGBA {
 	return m
 }
 
+// renderWalls casts every screen column, spread over a pool of workers sized to GOMAXPROCS.
+// Each column only touches its own pixels and zbuffer entries, so the result is identical to the serial path.
 func (c *RenderView) renderWalls(m *image.RGBA) {
 
-	for x := 0; x < c.renderWidth; x++ {
-		var step image.Point
+	workers := runtime.GOMAXPROCS(0)
+	if c.serial || workers < 2 {
+		for x := 0; x < c.renderWidth; x++ {
+			c.renderColumn(m, x)
+		}
+		return
+	}
 
-		worldX, worldY := int(c.position.X), int(c.position.Y)
+	if workers > c.renderWidth {
+		workers = c.renderWidth
+	}
 
-		cameraX := 2*float64(x)/float64(c.renderWidth) - 1
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for x := w; x < c.renderWidth; x += workers {
+				c.renderColumn(m, x)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
 
-		rayDir := pixel.V(
-			c.direction.X+c.plane.X*cameraX,
-			c.direction.Y+c.plane.Y*cameraX,
-		)
+// renderColumn draws the wall, floor and ceiling for a single screen column
+func (c *RenderView) renderColumn(m *image.RGBA, x int) {
+	g := c.game()
 
-		deltaDist := pixel.V(
-			math.Sqrt(1.0+(rayDir.Y*rayDir.Y)/(rayDir.X*rayDir.X)),
-			math.Sqrt(1.0+(rayDir.X*rayDir.X)/(rayDir.Y*rayDir.Y)),
-		)
+	var step image.Point
 
-		var sideDist pixel.Vec
-		if rayDir.X < 0 {
-			step.X = -1
-			sideDist.X = (c.position.X - float64(int(c.position.X))) * deltaDist.X
-		} else {
-			step.X = 1
-			sideDist.X = (float64(int(c.position.X)) + 1.0 - c.position.X) * deltaDist.X
-		}
+	worldX, worldY := int(c.position.X), int(c.position.Y)
 
-		if rayDir.Y < 0 {
-			step.Y = -1
-			sideDist.Y = (c.position.Y - float64(int(c.position.Y))) * deltaDist.Y
-		} else {
-			step.Y = 1
-			sideDist.Y = (float64(int(c.position.Y)) + 1.0 - c.position.Y) * deltaDist.Y
-		}
+	cameraX := 2*float64(x)/float64(c.renderWidth) - 1
 
-		var hit bool
-		var side bool
-		for !hit {
-			if sideDist.X < sideDist.Y {
-				sideDist.X += deltaDist.X
-				worldX += step.X
-				side = false
-			} else {
-				sideDist.Y += deltaDist.Y
-				worldY += step.Y
-				side = true
-			}
+	rayDir := pixel.V(
+		c.direction.X+c.plane.X*cameraX,
+		c.direction.Y+c.plane.Y*cameraX,
+	)
 
-			if c.parent.(*Player).game.mapData[worldX][worldY] > 0 {
-				hit = true
-			}
-			if c.parent.(*Player).game.mapData[worldX][worldY] == 3 {
-				hit = false
-			}
-		}
+	deltaDist := pixel.V(
+		math.Sqrt(1.0+(rayDir.Y*rayDir.Y)/(rayDir.X*rayDir.X)),
+		math.Sqrt(1.0+(rayDir.X*rayDir.X)/(rayDir.Y*rayDir.Y)),
+	)
 
-		var wallX float64
-		var perpWallDist float64
+	var sideDist pixel.Vec
+	if rayDir.X < 0 {
+		step.X = -1
+		sideDist.X = (c.position.X - float64(int(c.position.X))) * deltaDist.X
+	} else {
+		step.X = 1
+		sideDist.X = (float64(int(c.position.X)) + 1.0 - c.position.X) * deltaDist.X
+	}
 
-		if side {
-			perpWallDist = (float64(worldY) - c.position.Y + (1-float64(step.Y))/2) / rayDir.Y
-			wallX = c.position.X + perpWallDist*rayDir.X
+	if rayDir.Y < 0 {
+		step.Y = -1
+		sideDist.Y = (c.position.Y - float64(int(c.position.Y))) * deltaDist.Y
+	} else {
+		step.Y = 1
+		sideDist.Y = (float64(int(c.position.Y)) + 1.0 - c.position.Y) * deltaDist.Y
+	}
+
+	var hit bool
+	var side bool
+	var recess float64 // how far the wall that was hit lies inside its cell, half a cell for doors
+	var slide float64  // how far the door that was hit has slid open
+	for !hit {
+		if sideDist.X < sideDist.Y {
+			sideDist.X += deltaDist.X
+			worldX += step.X
+			side = false
 		} else {
-			perpWallDist = (float64(worldX) - c.position.X + (1-float64(step.X))/2) / rayDir.X
-			wallX = c.position.Y + perpWallDist*rayDir.Y
+			sideDist.Y += deltaDist.Y
+			worldY += step.Y
+			side = true
 		}
 
-		if x == c.renderWidth/2 {
-			c.distanceToWall = perpWallDist
+		if g.mapData[worldX][worldY] == 3 {
+			// the door stands in the middle of its cell, the ray passes when it leaves the cell before
+			// reaching the door or goes through the part that has slid into the wall
+			if d := g.doorAt(worldX, worldY); d != nil {
+				doorX, inCell := c.doorHit(worldX, worldY, side, rayDir)
+				if inCell && doorX >= d.open {
+					hit, recess, slide = true, 0.5, d.open
+				}
+			}
+		} else if g.mapData[worldX][worldY] > 0 {
+			hit = true
 		}
+	}
+
+	var wallX float64
+	var perpWallDist float64
+
+	if side {
+		perpWallDist = (float64(worldY) - c.position.Y + (1-float64(step.Y))/2 + recess*float64(step.Y)) / rayDir.Y
+		wallX = c.position.X + perpWallDist*rayDir.X
+	} else {
+		perpWallDist = (float64(worldX) - c.position.X + (1-float64(step.X))/2 + recess*float64(step.X)) / rayDir.X
+		wallX = c.position.Y + perpWallDist*rayDir.Y
+	}
 
-		wallX -= math.Floor(wallX)
+	if x == c.renderWidth/2 {
+		c.distanceToWall = perpWallDist
+	}
 
-		texX := int(wallX * float64(texSize))
+	wallX -= math.Floor(wallX)
 
-		lineHeight := int(float64(c.renderHeight) / perpWallDist)
+	// the texture of a door slides along with it
+	texX := int((wallX - slide) * float64(texSize))
 
-		if lineHeight < 1 {
-			lineHeight = 1
-		}
+	lineHeight := int(float64(c.renderHeight) / perpWallDist)
 
-		drawStart := -lineHeight/2 + c.renderHeight/2
-		if drawStart < 0 {
-			drawStart = 0
-		}
+	if lineHeight < 1 {
+		lineHeight = 1
+	}
 
-		drawEnd := lineHeight/2 + c.renderHeight/2
-		if drawEnd >= c.renderHeight {
-			drawEnd = c.renderHeight - 1
-		}
+	drawStart := -lineHeight/2 + c.renderHeight/2
+	if drawStart < 0 {
+		drawStart = 0
+	}
 
-		if !side && rayDir.X > 0 {
-			texX = texSize - texX - 1
-		}
+	drawEnd := lineHeight/2 + c.renderHeight/2
+	if drawEnd >= c.renderHeight {
+		drawEnd = c.renderHeight - 1
+	}
 
-		if side && rayDir.Y < 0 {
-			texX = texSize - texX - 1
-		}
+	if !side && rayDir.X > 0 {
+		texX = texSize - texX - 1
+	}
 
-		texNum := c.parent.(*Player).game.getTexNum(worldX, worldY)
-		if texNum == 4 {
-			texNum = 2
-		}
+	if side && rayDir.Y < 0 {
+		texX = texSize - texX - 1
+	}
+
+	texNum := g.getTexNum(worldX, worldY)
+	if texNum == 4 {
+		texNum = 2
+	}
+	if texNum == 3 {
+		texNum = doorTexture
+	}
+
+	hitPoint := c.position.Add(rayDir.Scaled(perpWallDist))
 
-		for y := drawStart; y < drawEnd+1; y++ {
-			texY := (float64(y) - float64(c.renderHeight)/2 + float64(lineHeight)/2) * texSize / float64(lineHeight)
+	// the light falling on the face of the wall, taken just in front of it
+	wallLight := 1.0
+	if g.lightMap != nil {
+		wallLight = g.lightMap.at(hitPoint.Sub(rayDir.Unit().Scaled(0.01)))
+	}
+
+	var shader *wallShader
+	if g.WallShading {
+		shader = c.newWallShader(texNum, hitPoint, side, step, rayDir)
+	}
 
-			col := c.parent.(*Player).game.textureMap.RGBAAt(
+	for y := drawStart; y < drawEnd+1; y++ {
+		texY := (float64(y) - float64(c.renderHeight)/2 + float64(lineHeight)/2) * texSize / float64(lineHeight)
+
+		var col color.RGBA
+		if shader != nil {
+			col = shader.texel(texX, texY)
+		} else {
+			col = g.textureMap.RGBAAt(
 				texX+texSize*(texNum),
 				int(texY)%texSize,
 			)
+		}
 
-			if side {
-				col.R = col.R / 2
-				col.G = col.G / 2
-				col.B = col.B / 2
-			}
+		if side {
+			col.R = col.R / 2
+			col.G = col.G / 2
+			col.B = col.B / 2
+		}
 
-			maxDistance := math.Max(float64(len(c.parent.(*Player).game.mapData)), float64(len(c.parent.(*Player).game.mapData[0])))
-			percentage := perpWallDist / maxDistance
-			// invert percentage
-			percentage = 1.0 - percentage
+		maxDistance := math.Max(float64(len(g.mapData)), float64(len(g.mapData[0])))
+		percentage := perpWallDist / maxDistance
+		// invert percentage
+		percentage = 1.0 - percentage
 
-			percentage = applyDistanceFalloff(percentage, perpWallDist)
-			if percentage < 1e-6 {
-				percentage = 1e-6
-			}
+		percentage = applyDistanceFalloff(percentage, perpWallDist) * wallLight
+		if percentage < 1e-6 {
+			percentage = 1e-6
+		}
 
-			// scale the color by the percentage
-			col.R = uint8(float64(col.R) * percentage)
-			col.G = uint8(float64(col.G) * percentage)
-			col.B = uint8(float64(col.B) * percentage)
+		// scale the color by the percentage
+		col.R = uint8(float64(col.R) * percentage)
+		col.G = uint8(float64(col.G) * percentage)
+		col.B = uint8(float64(col.B) * percentage)
 
-			m.Set(x, y, col)
+		m.Set(x, y, col)
 
-			// Calculate the zbuffer
-			zBufferValue := perpWallDist
-			// Store the zBuffer value in the zBuffer array
-			c.zBuffer[x][y] = zBufferValue
-		}
+		// Calculate the zbuffer
+		zBufferValue := perpWallDist
+		// Store the zBuffer value in the zBuffer array
+		c.zBuffer[x][y] = zBufferValue
+	}
 
-		if renderCeilingFloor {
-
-			var floorWall pixel.Vec
-
-			if !side && rayDir.X > 0 {
-				floorWall.X = float64(worldX)
-				floorWall.Y = float64(worldY) + wallX
-			} else if !side && rayDir.X < 0 {
-				floorWall.X = float64(worldX) + 1.0
-				floorWall.Y = float64(worldY) + wallX
-			} else if side && rayDir.Y > 0 {
-				floorWall.X = float64(worldX) + wallX
-				floorWall.Y = float64(worldY)
-			} else {
-				floorWall.X = float64(worldX) + wallX
-				floorWall.Y = float64(worldY) + 1.0
-			}
+	if renderCeilingFloor {
 
-			distWall, distPlayer := perpWallDist, 0.0
+		var floorWall pixel.Vec
 
-			for y := drawEnd + 1; y < c.renderHeight; y++ {
-				currentDist := float64(c.renderHeight) / (2.0*float64(y) - float64(c.renderHeight))
+		if !side && rayDir.X > 0 {
+			floorWall.X = float64(worldX) + recess
+			floorWall.Y = float64(worldY) + wallX
+		} else if !side && rayDir.X < 0 {
+			floorWall.X = float64(worldX) + 1.0 - recess
+			floorWall.Y = float64(worldY) + wallX
+		} else if side && rayDir.Y > 0 {
+			floorWall.X = float64(worldX) + wallX
+			floorWall.Y = float64(worldY) + recess
+		} else {
+			floorWall.X = float64(worldX) + wallX
+			floorWall.Y = float64(worldY) + 1.0 - recess
+		}
 
-				weight := (currentDist - distPlayer) / (distWall - distPlayer)
+		distWall, distPlayer := perpWallDist, 0.0
 
-				currentFloor := pixel.V(
-					weight*floorWall.X+(1.0-weight)*c.position.X,
-					weight*floorWall.Y+(1.0-weight)*c.position.Y,
-				)
+		for y := drawEnd + 1; y < c.renderHeight; y++ {
+			currentDist := float64(c.renderHeight) / (2.0*float64(y) - float64(c.renderHeight))
 
-				fx := int(currentFloor.X*float64(texSize)) % texSize
-				fy := int(currentFloor.Y*float64(texSize)) % texSize
+			weight := (currentDist - distPlayer) / (distWall - distPlayer)
 
-				perpFloorDist := currentDist
+			currentFloor := pixel.V(
+				weight*floorWall.X+(1.0-weight)*c.position.X,
+				weight*floorWall.Y+(1.0-weight)*c.position.Y,
+			)
 
-				maxDistance := math.Max(float64(len(c.parent.(*Player).game.mapData)), float64(len(c.parent.(*Player).game.mapData[0])))
-				percentage := perpFloorDist / maxDistance
-				// invert percentage
-				percentage = 1.0 - percentage
+			fx := int(currentFloor.X*float64(texSize)) % texSize
+			fy := int(currentFloor.Y*float64(texSize)) % texSize
 
-				percentage = applyDistanceFalloff(percentage, perpFloorDist)
-				if percentage < 1e-6 {
-					percentage = 1e-6
-				}
+			perpFloorDist := currentDist
 
-				// scale the color by the percentage
+			maxDistance := math.Max(float64(len(g.mapData)), float64(len(g.mapData[0])))
+			percentage := perpFloorDist / maxDistance
+			// invert percentage
+			percentage = 1.0 - percentage
 
-				col := c.parent.(*Player).game.textureMap.RGBAAt(fx+(0*texSize), fy)
-				col.R = uint8(float64(col.R) * percentage)
-				col.G = uint8(float64(col.G) * percentage)
-				col.B = uint8(float64(col.B) * percentage)
+			percentage = applyDistanceFalloff(percentage, perpFloorDist)
+			if g.lightMap != nil {
+				percentage *= g.lightMap.at(currentFloor)
+			}
+			if percentage < 1e-6 {
+				percentage = 1e-6
+			}
 
-				// Render floor
-				m.Set(x, y, col)
-				c.zBuffer[x][y] = perpFloorDist
+			// scale the color by the percentage
 
-				// Render roof
-				col = c.parent.(*Player).game.textureMap.RGBAAt(fx+(4*texSize), fy)
-				col.R = uint8(float64(col.R) * percentage)
-				col.G = uint8(float64(col.G) * percentage)
-				col.B = uint8(float64(col.B) * percentage)
-				m.Set(x, c.renderHeight-y-1, col)
-				m.Set(x, c.renderHeight-y, col)
+			col := g.textureMap.RGBAAt(fx+(0*texSize), fy)
+			col.R = uint8(float64(col.R) * percentage)
+			col.G = uint8(float64(col.G) * percentage)
+			col.B = uint8(float64(col.B) * percentage)
 
-				// Save this pixel to the z-buffer
-				c.zBuffer[x][c.renderHeight-y-1] = perpFloorDist
-				c.zBuffer[x][c.renderHeight-y] = perpFloorDist
-			}
+			// Render floor
+			m.Set(x, y, col)
+			c.zBuffer[x][y] = perpFloorDist
 
+			// Render roof
+			col = g.textureMap.RGBAAt(fx+(4*texSize), fy)
+			col.R = uint8(float64(col.R) * percentage)
+			col.G = uint8(float64(col.G) * percentage)
+			col.B = uint8(float64(col.B) * percentage)
+			m.Set(x, c.renderHeight-y-1, col)
+			m.Set(x, c.renderHeight-y, col)
+
+			// Save this pixel to the z-buffer
+			c.zBuffer[x][c.renderHeight-y-1] = perpFloorDist
+			c.zBuffer[x][c.renderHeight-y] = perpFloorDist
 		}
 
 	}
 }
 
+// doorHit intersects a ray that entered a door cell with the door in the middle of the cell. It returns where
+// along the door the ray hits it, from 0 to 1, and whether the ray reaches the door before leaving the cell.
+func (c *RenderView) doorHit(worldX int, worldY int, side bool, rayDir pixel.Vec) (float64, bool) {
+	var along float64
+	var cell int
+	if side {
+		dist := (float64(worldY) + 0.5 - c.position.Y) / rayDir.Y
+		along, cell = c.position.X+dist*rayDir.X, worldX
+	} else {
+		dist := (float64(worldX) + 0.5 - c.position.X) / rayDir.X
+		along, cell = c.position.Y+dist*rayDir.Y, worldY
+	}
+	if int(math.Floor(along)) != cell {
+		return 0, false
+	}
+	return along - math.Floor(along), true
+}
+
 var (
 	falloffPercentages = map[float64]float64{}
 	lightest           = 0.80
@@ -316,7 +397,12 @@ func roundDownToClosest(f float64) float64 {
 
 func (r *RenderView) renderThings(m *image.RGBA) {
 	r.isOtherPlayerSpriteVisible = false
-	for _, t := range r.parent.(*Player).game.gameObjects {
+	g := r.game()
+	for _, t := range g.gameObjects {
+		// a view never draws its own sprite
+		if t == r.parent {
+			continue
+		}
 
 		x := t.getPosition().X - r.position.X
 		y := t.getPosition().Y - r.position.Y
@@ -356,7 +442,7 @@ func (r *RenderView) renderThings(m *image.RGBA) {
 				for y := drawStartY; y < drawEndY; y++ {
 					d := y*256 - r.renderHeight*128 + spriteHeight*128
 					texY := ((d * texSize) / spriteHeight) / 256
-					c := r.parent.(*Player).game.textureMap.RGBAAt(texX+texSize*2.5, texY%texSize)
+					c := g.textureMap.RGBAAt(texX+texSize*2.5, texY%texSize)
 
 					if c.R != 0 {
 						if r.zBuffer[xx][y] > objectPerpDist {
@@ -389,6 +475,19 @@ func addLabel(img *image.RGBA, x, y int, label string) {
 
 func (c *RenderView) renderPosition(img *image.RGBA) {
 
-	addLabel(img, 10, 150, fmt.Sprintf("X: %f", c.parent.(*Player).getIntensityValuesAroundPlayer()[0]))
+	if p, ok := c.parent.(*Player); ok {
+		addLabel(img, 10, 150, fmt.Sprintf("X: %f", p.getIntensityValuesAroundPlayer()[0]))
+	}
 
 }
+
+// game returns the game instance of the player or enemy this view belongs to
+func (c *RenderView) game() *GameInstance {
+	switch p := c.parent.(type) {
+	case *Player:
+		return p.game
+	case *Enemy:
+		return p.game
+	}
+	return nil
+}
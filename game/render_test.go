@@ -0,0 +1,69 @@
+package game
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+// atRepoRoot runs f in the repository root, where the textures are loaded from the assets folder
+func atRepoRoot(tb testing.TB, f func()) {
+	wd, err := os.Getwd()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := os.Chdir(".."); err != nil {
+		tb.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	f()
+}
+
+// newTestGame returns a headless instance with views of the given size
+func newTestGame(tb testing.TB, width int, height int) *GameInstance {
+	var g *GameInstance
+	atRepoRoot(tb, func() {
+		g = NewHeadlessGame(width, height, 1)
+	})
+	return g
+}
+
+func TestRenderSerialMatchesParallel(t *testing.T) {
+	serial := newTestGame(t, 320, 240)
+	parallel := newTestGame(t, 320, 240)
+	serial.player1Controller.player.view.serial = true
+
+	for i := 0; i < 20; i++ {
+		a := serial.player1Controller.player.view.render()
+		b := parallel.player1Controller.player.view.render()
+		if !bytes.Equal(a.Pix, b.Pix) {
+			t.Fatalf("frame %d differs between the serial and the parallel caster", i)
+		}
+
+		serial.player1Controller.turnLeft(0.3)
+		parallel.player1Controller.turnLeft(0.3)
+	}
+}
+
+func benchmarkRender(b *testing.B, width int, height int) {
+	g := newTestGame(b, width, height)
+	view := g.player1Controller.player.view
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		view.render()
+		g.player1Controller.turnLeft(0.05)
+	}
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "fps")
+}
+
+func BenchmarkRender320x240(b *testing.B) {
+	benchmarkRender(b, 320, 240)
+}
+
+func BenchmarkRender640x480(b *testing.B) {
+	benchmarkRender(b, 640, 480)
+}
@@ -0,0 +1,147 @@
+package game
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// poseTolerance absorbs nothing but float formatting, a replay is expected to be exact
+const poseTolerance = 1e-9
+
+// ReplayOptions - what to do with the re-simulated frames
+type ReplayOptions struct {
+	FrameDir string                        // when set, the runner's view after every step is written here as PNG
+	OnFrame  func(step int, f *image.RGBA) // called with the runner's view after every step
+	Maps     []*Map                        // fixed maps the episode may have been played on
+}
+
+// ReplayMismatch - a value that came out differently than recorded
+type ReplayMismatch struct {
+	Step     int
+	Field    string
+	Expected string
+	Actual   string
+}
+
+func (m ReplayMismatch) String() string {
+	return fmt.Sprintf("step %d: %s expected %s, got %s", m.Step, m.Field, m.Expected, m.Actual)
+}
+
+// ReplayReport - the outcome of a replay
+type ReplayReport struct {
+	Steps      int
+	Mismatches []ReplayMismatch
+}
+
+// Replay re-simulates a logged episode through TakePlayer1Action and compares every reward,
+// done flag and pose with the log. Step -1 refers to the initial state.
+func Replay(episodeLog *EpisodeLog, opts ReplayOptions) (ReplayReport, error) {
+	report := ReplayReport{}
+
+	if opts.FrameDir != "" {
+		if err := os.MkdirAll(opts.FrameDir, 0755); err != nil {
+			return report, err
+		}
+	}
+
+	width, height := episodeLog.RenderWidth, episodeLog.RenderHeight
+	if width == 0 || height == 0 {
+		width, height = 640, 480
+	}
+
+	g := NewHeadlessGame(width, height, 0)
+	g.TicksPerStep = episodeLog.TicksPerStep
+	g.SecondsPerTick = episodeLog.SecondsPerTick
+	g.Maps = opts.Maps
+	g.Lighting = episodeLog.Lighting
+	g.WallShading = episodeLog.WallShading
+
+	if err := g.CheckResetConfig(episodeLog.Config); err != nil {
+		return report, err
+	}
+
+	// continue the clock where the recorded episode started and set up the same initial state
+	g.currentTick = episodeLog.StartTick
+	g.ResetWithConfig(episodeLog.Config)
+	g.player1Controller.player.view.setPose(episodeLog.Runner)
+	g.player2Controller.player.view.setPose(episodeLog.Chaser)
+	g.player1Controller.player.old_position = g.player1Controller.player.view.position
+	g.player1Controller.player.view.render()
+
+	if checksum := mapChecksum(g.mapData); checksum != episodeLog.MapChecksum {
+		report.Mismatches = append(report.Mismatches, ReplayMismatch{Step: -1, Field: "map", Expected: episodeLog.MapChecksum, Actual: checksum})
+		return report, nil
+	}
+
+	for i, step := range episodeLog.Steps {
+		var result RLActionResult
+		if step.ChaserAction != nil {
+			g.chaserAttached = true
+			result, _ = g.takeActions(step.Action, *step.ChaserAction)
+		} else {
+			g.chaserAttached = false
+			result = g.TakePlayer1Action(step.Action)
+		}
+		report.Steps++
+
+		report.compare(i, "reward", step.Reward == result.Reward, step.Reward, result.Reward)
+		report.compare(i, "done", step.Done == result.Done, step.Done, result.Done)
+		report.compare(i, "truncated", step.Truncated == result.Truncated, step.Truncated, result.Truncated)
+		runner := poseOf(g.player1Controller.player.view)
+		report.compare(i, "runner pose", runner.near(step.Runner), step.Runner, runner)
+		chaser := poseOf(g.player2Controller.player.view)
+		report.compare(i, "chaser pose", chaser.near(step.Chaser), step.Chaser, chaser)
+
+		if err := writeReplayFrame(g, i, opts); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func (r *ReplayReport) compare(step int, field string, equal bool, expected interface{}, actual interface{}) {
+	if !equal {
+		r.Mismatches = append(r.Mismatches, ReplayMismatch{Step: step, Field: field, Expected: fmt.Sprint(expected), Actual: fmt.Sprint(actual)})
+	}
+}
+
+func writeReplayFrame(g *GameInstance, step int, opts ReplayOptions) error {
+	g.renderListener.renderBufferMutex.Lock()
+	frame := g.renderListener.renderBuffer
+	g.renderListener.renderBufferMutex.Unlock()
+	if frame == nil {
+		return nil
+	}
+
+	if opts.OnFrame != nil {
+		opts.OnFrame(step, frame)
+	}
+
+	if opts.FrameDir == "" {
+		return nil
+	}
+
+	f, err := os.Create(filepath.Join(opts.FrameDir, fmt.Sprintf("frame-%05d.png", step)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, frame)
+}
+
+func (p Pose) near(o Pose) bool {
+	return math.Abs(p.X-o.X) < poseTolerance && math.Abs(p.Y-o.Y) < poseTolerance &&
+		math.Abs(p.DirX-o.DirX) < poseTolerance && math.Abs(p.DirY-o.DirY) < poseTolerance &&
+		math.Abs(p.PlaneX-o.PlaneX) < poseTolerance && math.Abs(p.PlaneY-o.PlaneY) < poseTolerance
+}
+
+func (c *RenderView) setPose(p Pose) {
+	c.position.X, c.position.Y = p.X, p.Y
+	c.direction.X, c.direction.Y = p.DirX, p.DirY
+	c.plane.X, c.plane.Y = p.PlaneX, p.PlaneY
+}
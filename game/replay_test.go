@@ -0,0 +1,54 @@
+package game
+
+import (
+	"image"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplayReproducesLoggedEpisodes(t *testing.T) {
+	actions := []RLAction{RLActionMoveForward, RLActionMoveForward, RLActionTurnLeft, RLActionMoveForward, RLActionUse, RLActionStrafeRight, RLActionTurnRight, RLActionMoveBackward}
+
+	for i, generator := range []MapGeneratorName{GeneratorScatter, GeneratorDungeon, GeneratorBSP, GeneratorMaze, GeneratorCaves} {
+		dir := t.TempDir()
+		g := newTestGame(t, 80, 60)
+		g.EpisodeLogDir = dir
+		g.Lighting = i%2 == 0
+		g.WallShading = i == 1
+		seed := int64(100 + i)
+		g.ResetWithConfig(ResetConfig{Seed: &seed, Generator: generator, ChaserBehaviour: ChaserRandomWalk, Difficulty: &Difficulty{Doors: true}})
+
+		for step := 0; step < 40; step++ {
+			if g.TakePlayer1Action(actions[step%len(actions)]).Done {
+				break
+			}
+		}
+		g.Reset() // writes the log
+
+		paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil || len(paths) != 1 {
+			t.Fatalf("%s: expected one episode log, got %v %v", generator, paths, err)
+		}
+		episodeLog, err := LoadEpisodeLog(paths[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var report ReplayReport
+		frames := 0
+		atRepoRoot(t, func() {
+			report, err = Replay(episodeLog, ReplayOptions{OnFrame: func(step int, f *image.RGBA) {
+				if f.Bounds().Dx() != 80 || f.Bounds().Dy() != 60 {
+					t.Fatalf("%s: step %d replayed at %v", generator, step, f.Bounds())
+				}
+				frames++
+			}})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Steps != len(episodeLog.Steps) || frames != report.Steps || len(report.Mismatches) != 0 {
+			t.Fatalf("%s: replayed %d of %d steps, %d frames, mismatches %v", generator, report.Steps, len(episodeLog.Steps), frames, report.Mismatches)
+		}
+	}
+}
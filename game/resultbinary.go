@@ -0,0 +1,213 @@
+package game
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Binary layout of an RLActionResult, all integers are big endian:
+//
+//	magic     [2]byte  "WR"
+//	version   uint8    binaryResultVersion
+//	flags     uint8    bit 0 done, bit 1 truncated
+//	reward    float32
+//	vecLen    uint32   number of float32 values that follow
+//	vector    [vecLen]float32
+//	encoding  uint8    0 none, 1 jpeg, 2 png, 3 rgb, 4 gray
+//	width     uint16
+//	height    uint16
+//	channels  uint8
+//	imgLen    uint32
+//	image     [imgLen]byte
+//	infoLen   uint16
+//	info      [infoLen]byte
+//
+// A vector of results is encoded as a uint32 count followed by uint32 length prefixed results.
+
+const binaryResultVersion = 1
+
+var binaryResultMagic = [2]byte{'W', 'R'}
+
+const (
+	resultFlagDone      = 1 << 0
+	resultFlagTruncated = 1 << 1
+)
+
+var binaryEncodings = []ObservationEncoding{"", ObservationJPEG, ObservationPNG, ObservationRGB, ObservationGray}
+
+func encodingToByte(e ObservationEncoding) (uint8, error) {
+	for i, enc := range binaryEncodings {
+		if enc == e {
+			return uint8(i), nil
+		}
+	}
+	return 0, errors.New("unknown observation encoding: " + string(e))
+}
+
+// MarshalBinary encodes the result in the compact binary step result layout
+func (r *RLActionResult) MarshalBinary() ([]byte, error) {
+	encoding, err := encodingToByte(r.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	if r.Width > math.MaxUint16 || r.Height > math.MaxUint16 || r.Channels > math.MaxUint8 {
+		return nil, errors.New("observation dimensions do not fit the binary layout")
+	}
+	if len(r.Info) > math.MaxUint16 {
+		return nil, errors.New("info does not fit the binary layout")
+	}
+
+	var flags uint8
+	if r.Done {
+		flags |= resultFlagDone
+	}
+	if r.Truncated {
+		flags |= resultFlagTruncated
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(23 + 4*len(r.Observation_Pos) + len(r.Observation) + len(r.Info))
+
+	buf.Write(binaryResultMagic[:])
+	buf.WriteByte(binaryResultVersion)
+	buf.WriteByte(flags)
+	binary.Write(&buf, binary.BigEndian, r.Reward)
+	binary.Write(&buf, binary.BigEndian, uint32(len(r.Observation_Pos)))
+	for _, v := range r.Observation_Pos {
+		binary.Write(&buf, binary.BigEndian, float32(v))
+	}
+	buf.WriteByte(encoding)
+	binary.Write(&buf, binary.BigEndian, uint16(r.Width))
+	binary.Write(&buf, binary.BigEndian, uint16(r.Height))
+	buf.WriteByte(uint8(r.Channels))
+	binary.Write(&buf, binary.BigEndian, uint32(len(r.Observation)))
+	buf.Write(r.Observation)
+	binary.Write(&buf, binary.BigEndian, uint16(len(r.Info)))
+	buf.WriteString(r.Info)
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a result written by MarshalBinary
+func (r *RLActionResult) UnmarshalBinary(data []byte) error {
+	rd := bytes.NewReader(data)
+
+	var header struct {
+		Magic   [2]byte
+		Version uint8
+		Flags   uint8
+		Reward  float32
+		VecLen  uint32
+	}
+	if err := binary.Read(rd, binary.BigEndian, &header); err != nil {
+		return errors.New("truncated step result header")
+	}
+	if header.Magic != binaryResultMagic {
+		return errors.New("not a binary step result")
+	}
+	if header.Version != binaryResultVersion {
+		return errors.New("unsupported binary step result version")
+	}
+	if int64(header.VecLen)*4 > int64(rd.Len()) {
+		return errors.New("truncated step result vector")
+	}
+
+	vec := make([]float32, header.VecLen)
+	if err := binary.Read(rd, binary.BigEndian, vec); err != nil {
+		return errors.New("truncated step result vector")
+	}
+
+	var img struct {
+		Encoding uint8
+		Width    uint16
+		Height   uint16
+		Channels uint8
+		Len      uint32
+	}
+	if err := binary.Read(rd, binary.BigEndian, &img); err != nil {
+		return errors.New("truncated step result image header")
+	}
+	if int(img.Encoding) >= len(binaryEncodings) {
+		return errors.New("unknown observation encoding in step result")
+	}
+	if int64(img.Len) > int64(rd.Len()) {
+		return errors.New("truncated step result image")
+	}
+	observation := make([]byte, img.Len)
+	rd.Read(observation)
+
+	var infoLen uint16
+	if err := binary.Read(rd, binary.BigEndian, &infoLen); err != nil {
+		return errors.New("truncated step result info")
+	}
+	if int(infoLen) > rd.Len() {
+		return errors.New("truncated step result info")
+	}
+	info := make([]byte, infoLen)
+	rd.Read(info)
+
+	*r = RLActionResult{
+		Reward:          header.Reward,
+		Observation:     observation,
+		Observation_Pos: make([]float64, len(vec)),
+		Done:            header.Flags&resultFlagDone != 0,
+		Truncated:       header.Flags&resultFlagTruncated != 0,
+		Info:            string(info),
+		Encoding:        binaryEncodings[img.Encoding],
+		Width:           int(img.Width),
+		Height:          int(img.Height),
+		Channels:        int(img.Channels),
+	}
+	for i, v := range vec {
+		r.Observation_Pos[i] = float64(v)
+	}
+
+	return nil
+}
+
+// MarshalBinaryResults encodes the results of a vectorized step
+func MarshalBinaryResults(results []RLActionResult) ([]byte, error) {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint32(len(results)))
+	for i := range results {
+		b, err := results[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		binary.Write(&buf, binary.BigEndian, uint32(len(b)))
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinaryResults decodes the results of a vectorized step written by MarshalBinaryResults
+func UnmarshalBinaryResults(data []byte) ([]RLActionResult, error) {
+	if len(data) < 4 {
+		return nil, errors.New("truncated step results")
+	}
+	count := binary.BigEndian.Uint32(data)
+	data = data[4:]
+
+	var results []RLActionResult
+	for i := uint32(0); i < count; i++ {
+		if len(data) < 4 {
+			return nil, errors.New("truncated step results")
+		}
+		n := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(n) > uint64(len(data)) {
+			return nil, errors.New("truncated step results")
+		}
+
+		var r RLActionResult
+		if err := r.UnmarshalBinary(data[:n]); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+		data = data[n:]
+	}
+
+	return results, nil
+}
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// binaryTestResults returns a result for every observation encoding, with and without the top-down channels,
+// and one without an observation or vector
+func binaryTestResults(t *testing.T) []RLActionResult {
+	frame := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	topDown := image.NewRGBA(frame.Bounds())
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 6; y++ {
+			frame.SetRGBA(x, y, color.RGBA{uint8(x * 30), uint8(y * 40), 90, 255})
+			topDown.SetRGBA(x, y, color.RGBA{200, uint8(x * y), 10, 255})
+		}
+	}
+
+	formats := []ObservationFormat{
+		{Encoding: ObservationJPEG, Quality: 60},
+		{Encoding: ObservationPNG},
+		{Encoding: ObservationRGB},
+		{Encoding: ObservationGray},
+		{Encoding: ObservationRGB, TopDown: true},
+		{Encoding: ObservationGray, TopDown: true},
+	}
+
+	var results []RLActionResult
+	for i, f := range formats {
+		obs, err := encodeObservation(frame, topDown, f)
+		if err != nil {
+			t.Fatalf("%+v: %v", f, err)
+		}
+		r := RLActionResult{
+			Reward:          -0.25 * float32(i),
+			Observation_Pos: []float64{0.5, -1.25, float64(i)},
+			Done:            i%2 == 1,
+			Truncated:       i%3 == 2,
+			Info:            "caught",
+		}
+		r.setObservation(obs)
+		results = append(results, r)
+	}
+
+	return append(results, RLActionResult{Reward: 1, Done: true, Truncated: true})
+}
+
+// sameResult reports whether two results hold the same values, nil and empty slices alike
+func sameResult(a RLActionResult, b RLActionResult) bool {
+	if len(a.Observation_Pos) != len(b.Observation_Pos) {
+		return false
+	}
+	for i := range a.Observation_Pos {
+		if a.Observation_Pos[i] != b.Observation_Pos[i] {
+			return false
+		}
+	}
+	return a.Reward == b.Reward && bytes.Equal(a.Observation, b.Observation) && a.Done == b.Done &&
+		a.Truncated == b.Truncated && a.Info == b.Info && a.Encoding == b.Encoding && a.Width == b.Width &&
+		a.Height == b.Height && a.Channels == b.Channels
+}
+
+func TestBinaryResultRoundTrip(t *testing.T) {
+	for _, want := range binaryTestResults(t) {
+		data, err := want.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%q %d channels: %v", want.Encoding, want.Channels, err)
+		}
+
+		var got RLActionResult
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%q %d channels: %v", want.Encoding, want.Channels, err)
+		}
+		if !sameResult(got, want) {
+			t.Fatalf("%q %d channels: decoded %+v, want %+v", want.Encoding, want.Channels, got, want)
+		}
+	}
+}
+
+func TestBinaryResultsRoundTrip(t *testing.T) {
+	for _, want := range [][]RLActionResult{binaryTestResults(t), nil} {
+		data, err := MarshalBinaryResults(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := UnmarshalBinaryResults(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("decoded %d results, want %d", len(got), len(want))
+		}
+		for i := range want {
+			if !sameResult(got[i], want[i]) {
+				t.Fatalf("result %d: decoded %+v, want %+v", i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestBinaryResultRejects(t *testing.T) {
+	results := binaryTestResults(t)
+	data, err := results[0].MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r RLActionResult
+	for n := 0; n < len(data); n++ {
+		if r.UnmarshalBinary(data[:n]) == nil {
+			t.Fatalf("accepted a result truncated to %d of %d bytes", n, len(data))
+		}
+	}
+
+	badMagic := append([]byte(nil), data...)
+	badMagic[0] = 'X'
+	if r.UnmarshalBinary(badMagic) == nil {
+		t.Fatal("accepted a bad magic")
+	}
+
+	badVersion := append([]byte(nil), data...)
+	badVersion[2] = binaryResultVersion + 1
+	if r.UnmarshalBinary(badVersion) == nil {
+		t.Fatal("accepted an unknown version")
+	}
+
+	vector, err := MarshalBinaryResults(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < len(vector); n++ {
+		if _, err := UnmarshalBinaryResults(vector[:n]); err == nil {
+			t.Fatalf("accepted vector step results truncated to %d of %d bytes", n, len(vector))
+		}
+	}
+}
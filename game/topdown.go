@@ -0,0 +1,242 @@
+package game
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// The top-down view draws map row x as image row x and map column y as image column y, the same
+// orientation as PrintMap and the Grid of map files. Positions are scaled by the cell size.
+
+// TopDownOptions - what to draw on top of the map
+type TopDownOptions struct {
+	CellSize int         // pixels per cell, default 8
+	Runner   *Pose       // drawn as a green dot with an arrow in its facing direction
+	Chaser   *Pose       // drawn as a red dot with an arrow in its facing direction
+	Path     []pixel.Vec // drawn as a line through the positions, like the autopilot's planned path or a trajectory
+	Visited  []pixel.Vec // the cells of these positions are tinted
+}
+
+const defaultTopDownCellSize = 8
+
+var (
+	topDownCells = map[int]color.RGBA{
+		0: {40, 40, 40, 255},    // floor
+		1: {140, 140, 140, 255}, // boundary
+		2: {95, 95, 115, 255},   // wall
+		3: {170, 110, 40, 255},  // door
+		4: {125, 85, 60, 255},   // room wall
+	}
+	topDownUnknown = color.RGBA{255, 0, 255, 255}
+	topDownGrid    = color.RGBA{0, 0, 0, 255}
+	topDownDoor    = color.RGBA{255, 160, 0, 255}
+	topDownLight   = color.RGBA{255, 230, 80, 255}
+	topDownVisited = color.RGBA{0, 90, 130, 255}
+	topDownPath    = color.RGBA{0, 220, 255, 255}
+	topDownRunner  = color.RGBA{60, 220, 60, 255}
+	topDownChaser  = color.RGBA{230, 50, 50, 255}
+)
+
+// RenderTopDown draws the map seen from above: the cells, the grid, the recorded doors, the lights and
+// whatever the options add
+func (m *Map) RenderTopDown(opts TopDownOptions) *image.RGBA {
+	cell := opts.CellSize
+	if cell <= 0 {
+		cell = defaultTopDownCellSize
+	}
+
+	rows, cols := len(m.mapData), 0
+	if rows > 0 {
+		cols = len(m.mapData[0])
+	}
+	img := image.NewRGBA(image.Rect(0, 0, cols*cell, rows*cell))
+
+	visited := make(map[[2]int]bool)
+	for _, p := range opts.Visited {
+		visited[[2]int{int(math.Floor(p.X)), int(math.Floor(p.Y))}] = true
+	}
+
+	for i, row := range m.mapData {
+		for j, c := range row {
+			col, ok := topDownCells[c]
+			if !ok {
+				col = topDownUnknown
+			}
+			if c == 0 && visited[[2]int{i, j}] {
+				col = topDownVisited
+			}
+			fillRect(img, j*cell, i*cell, cell, cell, col)
+
+			// the grid is only drawn where it leaves room for the cell
+			if cell >= 4 {
+				fillRect(img, j*cell, i*cell, cell, 1, topDownGrid)
+				fillRect(img, j*cell, i*cell, 1, cell, topDownGrid)
+			}
+		}
+	}
+
+	for _, door := range m.doors {
+		if len(door) != 2 {
+			continue
+		}
+		x, y := door[1]*cell, door[0]*cell
+		fillRect(img, x, y, cell, 1, topDownDoor)
+		fillRect(img, x, y+cell-1, cell, 1, topDownDoor)
+		fillRect(img, x, y, 1, cell, topDownDoor)
+		fillRect(img, x+cell-1, y, 1, cell, topDownDoor)
+	}
+
+	for _, light := range m.lights {
+		x, y := topDownPoint(light.position, cell)
+		fillCircle(img, x, y, math.Max(float64(cell)/5, 1), topDownLight)
+	}
+
+	for i := 1; i < len(opts.Path); i++ {
+		x0, y0 := topDownPoint(opts.Path[i-1], cell)
+		x1, y1 := topDownPoint(opts.Path[i], cell)
+		drawLine(img, x0, y0, x1, y1, topDownPath)
+	}
+
+	if opts.Chaser != nil {
+		drawPose(img, *opts.Chaser, cell, topDownChaser)
+	}
+	if opts.Runner != nil {
+		drawPose(img, *opts.Runner, cell, topDownRunner)
+	}
+
+	return img
+}
+
+// topDownPoint returns the pixel of a map position
+func topDownPoint(p pixel.Vec, cell int) (int, int) {
+	return int(p.Y * float64(cell)), int(p.X * float64(cell))
+}
+
+// drawPose draws a player as a dot with an arrow of one cell in its facing direction
+func drawPose(img *image.RGBA, p Pose, cell int, c color.RGBA) {
+	x, y := topDownPoint(pixel.V(p.X, p.Y), cell)
+	fillCircle(img, x, y, math.Max(float64(cell)*0.35, 1), c)
+
+	length := math.Hypot(p.DirX, p.DirY)
+	if length == 0 {
+		return
+	}
+	dx, dy := p.DirY/length, p.DirX/length
+	reach := float64(cell) * 1.2
+	tipX, tipY := x+int(math.Round(dx*reach)), y+int(math.Round(dy*reach))
+	drawLine(img, x, y, tipX, tipY, c)
+
+	// the two barbs of the arrow head point back at 30 degrees from the shaft
+	barb := float64(cell) * 0.5
+	for _, angle := range []float64{math.Pi * 5 / 6, -math.Pi * 5 / 6} {
+		sin, cos := math.Sincos(angle)
+		bx, by := dx*cos-dy*sin, dx*sin+dy*cos
+		drawLine(img, tipX, tipY, tipX+int(math.Round(bx*barb)), tipY+int(math.Round(by*barb)), c)
+	}
+}
+
+func fillRect(img *image.RGBA, x int, y int, w int, h int, c color.RGBA) {
+	r := image.Rect(x, y, x+w, y+h).Intersect(img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			img.SetRGBA(px, py, c)
+		}
+	}
+}
+
+func fillCircle(img *image.RGBA, x int, y int, radius float64, c color.RGBA) {
+	r := int(math.Ceil(radius))
+	for py := y - r; py <= y+r; py++ {
+		for px := x - r; px <= x+r; px++ {
+			dx, dy := float64(px-x), float64(py-y)
+			if dx*dx+dy*dy <= radius*radius && image.Pt(px, py).In(img.Bounds()) {
+				img.SetRGBA(px, py, c)
+			}
+		}
+	}
+}
+
+// drawLine draws a line with Bresenham's algorithm, pixels outside the image are skipped
+func drawLine(img *image.RGBA, x0 int, y0 int, x1 int, y1 int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	e := dx + dy
+	for {
+		if image.Pt(x0, y0).In(img.Bounds()) {
+			img.SetRGBA(x0, y0, c)
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// CurrentMap returns a copy of the map of the current episode
+func (g *GameInstance) CurrentMap() *Map {
+	m := Map{mapData: g.mapData, lights: g.lights, doors: g.doors}
+	c := m.copyFor(nil)
+	c.rows, c.cols = len(c.mapData), 0
+	if c.rows > 0 {
+		c.cols = len(c.mapData[0])
+	}
+	return &c
+}
+
+// RenderTopDown draws the current episode from above: the map, both players, the autopilot's planned path
+// and the cells the runner visited so far
+func (g *GameInstance) RenderTopDown(cellSize int) *image.RGBA {
+	runner := poseOf(g.player1Controller.player.view)
+	chaser := poseOf(g.player2Controller.player.view)
+
+	opts := TopDownOptions{CellSize: cellSize, Runner: &runner, Chaser: &chaser, Visited: g.trail}
+	if g.runnerAutopilot != nil && len(g.runnerAutopilot.path) > 0 {
+		opts.Path = append([]pixel.Vec{pixel.V(runner.X, runner.Y)}, g.runnerAutopilot.path...)
+	}
+	return g.CurrentMap().RenderTopDown(opts)
+}
+
+// resample scales an image to w x h pixels, picking the nearest pixel
+func resample(img *image.RGBA, w int, h int) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	b := img.Bounds()
+	if b.Empty() {
+		return out
+	}
+	for y := 0; y < h; y++ {
+		sy := b.Min.Y + y*b.Dy()/h
+		for x := 0; x < w; x++ {
+			out.SetRGBA(x, y, img.RGBAAt(b.Min.X+x*b.Dx()/w, sy))
+		}
+	}
+	return out
+}
+
+// topDownObservation renders the top-down view of the current episode at the size of the observations, the
+// cells are made large enough to fill the size before it is resampled to it
+func (g *GameInstance) topDownObservation(w int, h int) *image.RGBA {
+	rows, cols := len(g.mapData), 0
+	if rows > 0 {
+		cols = len(g.mapData[0])
+	}
+	if cols == 0 {
+		return image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+
+	cell := 1
+	for cell*rows < h || cell*cols < w {
+		cell++
+	}
+	return resample(g.RenderTopDown(cell), w, h)
+}
@@ -0,0 +1,73 @@
+package game
+
+import (
+	"image"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestRenderTopDown(t *testing.T) {
+	f := mapFile{Version: mapFileVersion, Grid: []string{
+		"#####",
+		"#...#",
+		"#.=.#",
+		"#####",
+	}}
+	m, err := f.toMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runner := Pose{X: 1.5, Y: 1.5, DirX: 0, DirY: 1}
+	img := m.RenderTopDown(TopDownOptions{CellSize: 10, Runner: &runner, Visited: []pixel.Vec{pixel.V(1.5, 3.5)}})
+	if img.Bounds() != image.Rect(0, 0, 50, 40) {
+		t.Fatalf("unexpected bounds %v", img.Bounds())
+	}
+
+	// rows run down the image, columns across
+	if c := img.RGBAAt(25, 25); c != topDownCells[4] {
+		t.Fatalf("room wall at row 2, column 2 drawn as %v", c)
+	}
+	if c := img.RGBAAt(15, 25); c != topDownCells[0] {
+		t.Fatalf("floor at row 2, column 1 drawn as %v", c)
+	}
+	if c := img.RGBAAt(35, 15); c != topDownVisited {
+		t.Fatalf("visited cell drawn as %v", c)
+	}
+
+	// the runner faces along the columns, its arrow points right
+	if c := img.RGBAAt(15, 15); c != topDownRunner {
+		t.Fatalf("runner drawn as %v", c)
+	}
+	if c := img.RGBAAt(26, 15); c != topDownRunner {
+		t.Fatalf("runner's arrow drawn as %v", c)
+	}
+	if c := img.RGBAAt(15, 26); c == topDownRunner {
+		t.Fatal("runner's arrow points down")
+	}
+}
+
+func TestEncodeObservationTopDown(t *testing.T) {
+	frame := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	topDown := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	topDown.Pix[0] = 200
+
+	obs, err := encodeObservation(frame, topDown, ObservationFormat{Encoding: ObservationRGB, TopDown: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obs.Channels != 6 || len(obs.Data) != 4*2*6 {
+		t.Fatalf("got %d channels and %d bytes", obs.Channels, len(obs.Data))
+	}
+	if obs.Data[0] != 0 || obs.Data[3] != 200 {
+		t.Fatalf("top-down channels don't follow the frame's: %v", obs.Data[:6])
+	}
+
+	if _, err := encodeObservation(frame, image.NewRGBA(image.Rect(0, 0, 2, 2)), ObservationFormat{Encoding: ObservationGray, TopDown: true}); err == nil {
+		t.Fatal("expected an error for a top-down view of another size")
+	}
+	if err := (ObservationFormat{Encoding: ObservationJPEG, Quality: 60, TopDown: true}).Validate(); err == nil {
+		t.Fatal("expected the jpeg encoding to reject the top-down channels")
+	}
+}
@@ -0,0 +1,196 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/faiface/pixel"
+)
+
+// MapReport - sanity checks and statistics of a map
+type MapReport struct {
+	Rows, Cols int
+
+	FloorCells       int  // walkable cells
+	ReachableCells   int  // floor cells of the largest area, where the players spawn
+	Regions          int  // separate floor areas
+	Pockets          int  // floor areas that can't be reached from the largest one
+	UnreachableCells int  // floor cells in pockets
+	Connected        bool // every floor cell is reachable from every other one
+
+	Doors     int // doors recorded by the generator
+	DeadEnds  int // floor cells with a single floor neighbour
+	SpawnArea int // cells of the largest area whose four neighbours are floor too
+
+	SpawnsReachable bool // the players can spawn where they reach each other, including fixed spawns
+
+	Problems []string // what makes the map unplayable, empty for a sane map
+}
+
+// Report checks the map and collects its statistics
+func (m *Map) Report() MapReport {
+	r := MapReport{Rows: len(m.mapData), Doors: len(m.doors)}
+	if r.Rows > 0 {
+		r.Cols = len(m.mapData[0])
+	}
+
+	for i, row := range m.mapData {
+		if len(row) != r.Cols {
+			r.Problems = append(r.Problems, fmt.Sprintf("row %d has %d cells, expected %d", i, len(row), r.Cols))
+			return r
+		}
+		for j, cell := range row {
+			if cell < 0 {
+				r.Problems = append(r.Problems, fmt.Sprintf("cell %d,%d has the invalid value %d", i, j, cell))
+			}
+			if cell != 0 {
+				continue
+			}
+
+			r.FloorCells++
+			if i == 0 || j == 0 || i == r.Rows-1 || j == r.Cols-1 {
+				r.Problems = append(r.Problems, fmt.Sprintf("floor cell %d,%d on the boundary", i, j))
+			}
+			if floorNeighbours(m.mapData, i, j) == 1 {
+				r.DeadEnds++
+			}
+		}
+	}
+	if len(r.Problems) > 0 {
+		return r
+	}
+
+	main := largestRegion(m.mapData)
+	seen := make([][]bool, r.Rows)
+	for i := range seen {
+		seen[i] = make([]bool, r.Cols)
+	}
+	for i, row := range m.mapData {
+		for j, cell := range row {
+			if cell != 0 {
+				continue
+			}
+			if main[i][j] {
+				r.ReachableCells++
+				if emptyWithin(&m.mapData, i, j, 1) {
+					r.SpawnArea++
+				}
+			}
+			if !seen[i][j] {
+				r.Regions++
+				markRegion(seen, floodFill(m.mapData, i, j))
+			}
+		}
+	}
+	r.Pockets = r.Regions - 1
+	if r.Regions == 0 {
+		r.Pockets = 0
+	}
+	r.UnreachableCells = r.FloorCells - r.ReachableCells
+	r.Connected = r.Regions <= 1
+
+	for _, door := range m.doors {
+		if len(door) != 2 || door[0] < 0 || door[1] < 0 || door[0] >= r.Rows || door[1] >= r.Cols {
+			r.Problems = append(r.Problems, fmt.Sprintf("door %v is outside the map", door))
+		}
+	}
+
+	// random spawns are picked in the largest area, two fixed spawns only have to share an area
+	switch {
+	case m.runnerSpawn != nil && m.chaserSpawn != nil:
+		x, y := int(math.Floor(m.runnerSpawn.X)), int(math.Floor(m.runnerSpawn.Y))
+		r.SpawnsReachable = isWalkable(m.mapData, *m.runnerSpawn) && inRegion(floodFill(m.mapData, x, y), *m.chaserSpawn)
+	case m.runnerSpawn != nil:
+		r.SpawnsReachable = r.ReachableCells >= 2 && inRegion(main, *m.runnerSpawn)
+	case m.chaserSpawn != nil:
+		r.SpawnsReachable = r.ReachableCells >= 2 && inRegion(main, *m.chaserSpawn)
+	default:
+		r.SpawnsReachable = r.ReachableCells >= 2
+	}
+
+	if r.FloorCells == 0 {
+		r.Problems = append(r.Problems, "no floor")
+	} else if !r.SpawnsReachable {
+		r.Problems = append(r.Problems, "the players can't spawn where they reach each other")
+	}
+
+	return r
+}
+
+// Err returns the problems of the map as an error, nil for a sane map
+func (r MapReport) Err() error {
+	if len(r.Problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(r.Problems, ", "))
+}
+
+func floorNeighbours(mapData [][]int, x int, y int) int {
+	n := 0
+	for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+		i, j := x+d[0], y+d[1]
+		if i >= 0 && j >= 0 && i < len(mapData) && j < len(mapData[i]) && mapData[i][j] == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func markRegion(seen [][]bool, region [][]bool) {
+	for i := range region {
+		for j := range region[i] {
+			if region[i][j] {
+				seen[i][j] = true
+			}
+		}
+	}
+}
+
+// largestRegion marks the floor cells of the largest connected floor area
+func largestRegion(mapData [][]int) [][]bool {
+	seen := make([][]bool, len(mapData))
+	for i := range mapData {
+		seen[i] = make([]bool, len(mapData[i]))
+	}
+
+	var largest [][]bool
+	largestSize := 0
+	for i := range mapData {
+		for j := range mapData[i] {
+			if mapData[i][j] != 0 || seen[i][j] {
+				continue
+			}
+
+			region := floodFill(mapData, i, j)
+			markRegion(seen, region)
+			if size := countCells(region); size > largestSize {
+				largest, largestSize = region, size
+			}
+		}
+	}
+
+	if largest == nil {
+		largest = seen
+	}
+	return largest
+}
+
+func countCells(region [][]bool) int {
+	n := 0
+	for i := range region {
+		for j := range region[i] {
+			if region[i][j] {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// inRegion reports whether a position lies in a cell of the region
+func inRegion(region [][]bool, p pixel.Vec) bool {
+	x, y := int(math.Floor(p.X)), int(math.Floor(p.Y))
+	return x >= 0 && y >= 0 && x < len(region) && y < len(region[x]) && region[x][y]
+}
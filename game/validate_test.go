@@ -0,0 +1,88 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestMapReport(t *testing.T) {
+	f := mapFile{Version: mapFileVersion, Grid: []string{
+		"########",
+		"#....#.#",
+		"#.##.#.#",
+		"#....#.#",
+		"########",
+	}}
+	m, err := f.toMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := m.Report()
+	if r.FloorCells != 13 || r.ReachableCells != 10 || r.Regions != 2 || r.Pockets != 1 || r.UnreachableCells != 3 || r.Connected {
+		t.Fatalf("unexpected areas %+v", r)
+	}
+	if r.DeadEnds != 2 {
+		t.Fatalf("dead ends = %d, expected 2", r.DeadEnds)
+	}
+	if !r.SpawnsReachable || r.Err() != nil {
+		t.Fatalf("expected a playable map, got %v", r.Err())
+	}
+
+	// fixed spawns in separate areas can't meet
+	runner, chaser := pixel.V(1.5, 1.5), pixel.V(1.5, 6.5)
+	m.runnerSpawn, m.chaserSpawn = &runner, &chaser
+	if r := m.Report(); r.SpawnsReachable || r.Err() == nil {
+		t.Fatal("expected the spawns to be reported unreachable")
+	}
+}
+
+func TestSpawnsAvoidPockets(t *testing.T) {
+	pockets := 0
+	for seed := int64(1); seed <= 100; seed++ {
+		m := ScatterGenerator{}.Generate(48, 48, seed)
+		if !m.Report().Connected {
+			pockets++
+		}
+
+		runner, chaser := spawnPlayers(m, rand.New(rand.NewSource(seed)), Difficulty{})
+		x, y := int(runner.X), int(runner.Y)
+		if !inRegion(floodFill(m.mapData, x, y), chaser) {
+			t.Fatalf("seed %d: the chaser at %v can't reach the runner at %v", seed, chaser, runner)
+		}
+	}
+	if pockets == 0 {
+		t.Fatal("expected some scatter maps with closed rooms")
+	}
+}
+
+func TestDistToNearestWall(t *testing.T) {
+	f := mapFile{Version: mapFileVersion, Grid: []string{
+		"#######",
+		"#.....#",
+		"#.....#",
+		"#.....#",
+		"#######",
+	}}
+	m, err := f.toMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &GameInstance{mapData: m.mapData}
+
+	for _, c := range []struct {
+		position pixel.Vec
+		expected float64
+	}{
+		{pixel.V(2.5, 3.5), 2},
+		{pixel.V(1.5, 1.5), 1},
+		{pixel.V(0.5, 0.5), 0},
+		{pixel.V(-3, 20), 0},
+	} {
+		if d := g.distToNearestWall(c.position, 0); d != c.expected {
+			t.Errorf("%v: distance %v, expected %v", c.position, d, c.expected)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package game
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// Wall shading lights the walls per pixel with the normal map (assets/normal.png) and shifts their texture
+// lookups with the displacement map (assets/disp.png), both laid out like the texture atlas. The bumps are
+// lit by the light sources in reach of the wall and by a light at the camera. They are shaded relative to
+// the flat wall, so a wall keeps the brightness of the distance falloff and the light map.
+
+const (
+	parallaxScale    = 0.04 // texture widths the highest displacement shifts the lookup when viewed at 45 degrees
+	maxParallaxSlope = 4.0  // limits the shift when the wall is viewed at a grazing angle
+	eyeHeight        = 0.5  // height of the camera and the light sources above the floor, walls are 1 high
+	cameraLight      = 0.5  // weight of the light at the camera next to a light source at full intensity
+	maxBumpShade     = 2.0  // brightest a bump gets relative to the flat wall
+	bumpStrength     = 0.7  // scales how far the normals of the normal map tilt away from the face
+)
+
+// vec3 - a point or direction in the world, z points up
+type vec3 struct {
+	x, y, z float64
+}
+
+func (a vec3) add(b vec3) vec3 {
+	return vec3{a.x + b.x, a.y + b.y, a.z + b.z}
+}
+
+func (a vec3) sub(b vec3) vec3 {
+	return vec3{a.x - b.x, a.y - b.y, a.z - b.z}
+}
+
+func (a vec3) scaled(s float64) vec3 {
+	return vec3{a.x * s, a.y * s, a.z * s}
+}
+
+func (a vec3) dot(b vec3) float64 {
+	return a.x*b.x + a.y*b.y + a.z*b.z
+}
+
+func (a vec3) unit() vec3 {
+	l := math.Sqrt(a.dot(a))
+	if l == 0 {
+		return a
+	}
+	return a.scaled(1 / l)
+}
+
+var worldUp = vec3{0, 0, 1}
+
+// shadingLight - a light that reaches the wall a column's ray hit
+type shadingLight struct {
+	position vec3
+	weight   float64
+}
+
+// wallShader - shades the texels of the wall face a column's ray hit
+type wallShader struct {
+	g       *GameInstance
+	texNum  int
+	hit     pixel.Vec // where the ray hit the face
+	normal  vec3      // of the face, towards the camera
+	tangent vec3      // direction the texture x grows along the face
+	eye     vec3
+	lights  []shadingLight
+}
+
+// newWallShader sets up the shading of the face hit by a ray: side and step of the DDA tell which face it is
+func (c *RenderView) newWallShader(texNum int, hit pixel.Vec, side bool, step image.Point, rayDir pixel.Vec) *wallShader {
+	g := c.game()
+	s := &wallShader{g: g, texNum: texNum, hit: hit, eye: vec3{c.position.X, c.position.Y, eyeHeight}}
+
+	// texX is flipped on the faces renderColumn mirrors, the tangent follows
+	if side {
+		s.normal = vec3{0, -float64(step.Y), 0}
+		s.tangent = vec3{1, 0, 0}
+		if rayDir.Y < 0 {
+			s.tangent = vec3{-1, 0, 0}
+		}
+	} else {
+		s.normal = vec3{-float64(step.X), 0, 0}
+		s.tangent = vec3{0, 1, 0}
+		if rayDir.X > 0 {
+			s.tangent = vec3{0, -1, 0}
+		}
+	}
+
+	s.lights = []shadingLight{{s.eye, cameraLight}}
+	front := hit.Add(pixel.V(s.normal.x, s.normal.y).Scaled(0.01))
+	for _, light := range g.lights {
+		d := hit.Sub(light.position).Len()
+		if d >= light.radius {
+			continue
+		}
+		position := vec3{light.position.X, light.position.Y, eyeHeight}
+		if position.sub(vec3{hit.X, hit.Y, eyeHeight}).dot(s.normal) <= 0 || !lightReaches(g.mapData, light.position, front) {
+			continue
+		}
+		falloff := 1 - d/light.radius
+		s.lights = append(s.lights, shadingLight{position, lightIntensity * falloff * falloff})
+	}
+
+	return s
+}
+
+// texel returns the shaded texel of the face at texture column texX and texture row texY
+func (s *wallShader) texel(texX int, texY float64) color.RGBA {
+	p := vec3{s.hit.X, s.hit.Y, 1 - texY/texSize}
+
+	// shift the lookup along the view by the displacement, the higher the texel the more
+	view := s.eye.sub(p)
+	toward := math.Max(view.dot(s.normal), 1e-6)
+	slopeX := clamp(view.dot(s.tangent)/toward, -maxParallaxSlope, maxParallaxSlope)
+	slopeY := clamp(view.dot(worldUp)/toward, -maxParallaxSlope, maxParallaxSlope)
+
+	height := float64(s.g.dispMap.RGBAAt(texX+texSize*s.texNum, int(texY)%texSize).R)/255 - 0.5
+	tx := wrapTexel(float64(texX) + slopeX*height*parallaxScale*texSize)
+	ty := wrapTexel(texY - slopeY*height*parallaxScale*texSize) // texture rows grow downwards
+
+	col := s.g.textureMap.RGBAAt(tx+texSize*s.texNum, ty)
+
+	// the normal map holds the bump's normal in the space of the face: x along the texture in red, y up in
+	// green. Out of the face is rebuilt from the two, the blue channel of some textures is too dark to use.
+	nc := s.g.normalMap.RGBAAt(tx+texSize*s.texNum, ty)
+	nx, ny := (float64(nc.R)/127.5-1)*bumpStrength, (float64(nc.G)/127.5-1)*bumpStrength
+	n := s.tangent.scaled(nx).
+		add(worldUp.scaled(ny)).
+		add(s.normal.scaled(math.Sqrt(math.Max(1-nx*nx-ny*ny, 0)))).unit()
+
+	var bump, flat float64
+	for _, light := range s.lights {
+		l := light.position.sub(p).unit()
+		bump += light.weight * math.Max(n.dot(l), 0)
+		flat += light.weight * math.Max(s.normal.dot(l), 0)
+	}
+	if flat <= 0 {
+		return col
+	}
+
+	shade := math.Min(bump/flat, maxBumpShade)
+	col.R = uint8(math.Min(float64(col.R)*shade, 255))
+	col.G = uint8(math.Min(float64(col.G)*shade, 255))
+	col.B = uint8(math.Min(float64(col.B)*shade, 255))
+	return col
+}
+
+// clamp limits v to the range from lo to hi
+func clamp(v float64, lo float64, hi float64) float64 {
+	return math.Max(lo, math.Min(v, hi))
+}
+
+// wrapTexel wraps a texture coordinate into its texture
+func wrapTexel(v float64) int {
+	i := int(math.Floor(v)) % texSize
+	if i < 0 {
+		i += texSize
+	}
+	return i
+}
@@ -0,0 +1,73 @@
+package game
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestWallShading(t *testing.T) {
+	fill := func(c color.RGBA) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, texSize, texSize))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		return img
+	}
+
+	wall := color.RGBA{100, 100, 100, 255}
+	g := &GameInstance{
+		mapData:    [][]int{{1, 1, 1}, {1, 0, 1}, {1, 0, 1}, {1, 1, 1}},
+		textureMap: fill(wall),
+		normalMap:  fill(color.RGBA{128, 128, 255, 255}),
+		dispMap:    fill(color.RGBA{128, 128, 128, 255}),
+	}
+	view := &RenderView{position: pixel.V(1.3, 1.5), direction: pixel.V(-1, 0), plane: pixel.V(0, 0.66)}
+	view.parent = &Player{game: g, view: view}
+
+	// the camera looks at the face of the wall at x 1, texture x grows along y
+	s := view.newWallShader(0, pixel.V(1, 1.5), false, image.Pt(-1, 0), pixel.V(-1, 0))
+
+	// a flat normal map and no displacement leave the wall as it is, up to the rounding of the 8 bit normals
+	if c := s.texel(32, 32); c.R < wall.R-1 || c.R > wall.R+1 || c.A != wall.A {
+		t.Fatalf("flat texel shaded to %v", c)
+	}
+
+	// high up the wall, a bump tilted down towards the camera is brighter, one tilted away darker
+	g.normalMap = fill(color.RGBA{128, 40, 255, 255})
+	if c := s.texel(32, 2); c.R <= wall.R {
+		t.Fatalf("bump facing the camera shaded to %v", c)
+	}
+	g.normalMap = fill(color.RGBA{128, 216, 255, 255})
+	if c := s.texel(32, 2); c.R >= wall.R {
+		t.Fatalf("bump facing away from the camera shaded to %v", c)
+	}
+
+	// seen from the side and from below, the displacement shifts the lookup along the view: the texture
+	// holds each texel's coordinates, the camera is 0.3 in front of the face and 0.3 to its side
+	g.normalMap = fill(color.RGBA{128, 128, 255, 255})
+	g.textureMap = image.NewRGBA(image.Rect(0, 0, texSize, texSize))
+	for x := 0; x < texSize; x++ {
+		for y := 0; y < texSize; y++ {
+			g.textureMap.SetRGBA(x, y, color.RGBA{uint8(4 * x), uint8(4 * y), 0, 255})
+		}
+	}
+	view.position = pixel.V(1.3, 1.2)
+	s = view.newWallShader(0, pixel.V(1, 1.5), false, image.Pt(-1, 0), pixel.V(-1, 0))
+
+	for _, tc := range []struct {
+		disp uint8
+		x, y int
+	}{
+		{255, 30, 17}, // raised texels show the texels towards the camera
+		{0, 33, 15},   // sunken ones those away from it
+	} {
+		g.dispMap = fill(color.RGBA{tc.disp, tc.disp, tc.disp, 255})
+		c := s.texel(32, 16.5)
+		if x, y := (int(c.R)+2)/4, (int(c.G)+2)/4; x != tc.x || y != tc.y {
+			t.Fatalf("displacement %d looked up texel %d,%d, expected %d,%d", tc.disp, x, y, tc.x, tc.y)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+//go:build !headless
+
+package game
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"image/color"
+	"time"
+)
+
+// The window, the keyboard input and the game loop need pixelgl, which links against GLFW and OpenGL.
+// Building with the headless tag leaves them out, see window_headless.go.
+
+// window - the pixelgl window the runner's view is shown in
+type window struct {
+	*pixelgl.Window
+	cfg pixelgl.WindowConfig
+}
+
+// RunWindow runs f on the main thread, as pixelgl requires of everything that opens a window
+func RunWindow(f func()) {
+	pixelgl.Run(f)
+}
+
+func (g *GameInstance) openWindow() {
+	cfg := pixelgl.WindowConfig{
+		Bounds:      pixel.R(0, 0, float64(g.RenderWidth)*g.RenderScale, float64(g.RenderHeight)*g.RenderScale),
+		VSync:       true,
+		Undecorated: false,
+		Resizable:   true,
+	}
+
+	if g.RenderFullscreen {
+		cfg.Monitor = pixelgl.PrimaryMonitor()
+	}
+
+	win, err := pixelgl.NewWindow(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	g.center = win.Bounds().Center()
+
+	g.win = &window{Window: win, cfg: cfg}
+}
+
+func (g *GameInstance) GameLoop() {
+
+	g.gameInit()
+
+	g.addGameObjects()
+
+	g.Reset()
+
+	last := time.Now()
+
+	for !g.win.Closed() {
+		if g.win.JustPressed(pixelgl.KeyEscape) || g.win.JustPressed(pixelgl.KeyQ) {
+			return
+		}
+
+		g.win.Clear(color.Black)
+
+		dt := time.Since(last).Seconds()
+		last = time.Now()
+
+		g.updateGameEntities(dt)
+
+		if g.win.JustPressed(pixelgl.KeyP) {
+			g.UseAutopilot = !g.UseAutopilot
+		}
+
+		// Process player input, or let the autopilot drive the runner
+		if g.UseAutopilot {
+			g.stepAutopilot()
+		} else {
+			g.player1Controller.processInput(g.win, dt)
+		}
+
+		// Render player1's view
+		if g.renderListener.renderBuffer != nil {
+			g.renderListener.renderBufferMutex.Lock()
+			p := pixel.PictureDataFromImage(g.renderListener.renderBuffer)
+			pixel.NewSprite(p, p.Bounds()).
+				Draw(g.win, pixel.IM.Moved(g.center).Scaled(g.center, g.RenderScale))
+			g.renderListener.renderBufferMutex.Unlock()
+		} else {
+			g.player1Controller.player.view.render()
+		}
+
+		g.win.Update()
+	}
+}
@@ -0,0 +1,25 @@
+//go:build headless
+
+package game
+
+import "log"
+
+// Built with the headless tag, the game has no window and doesn't link against GLFW and OpenGL.
+// Instances have to be created with NewHeadlessGame and stepped over IPC.
+
+// window - there is no window in a headless build
+type window struct{}
+
+// RunWindow runs f, there is no main thread to hand it to
+func RunWindow(f func()) {
+	f()
+}
+
+func (g *GameInstance) openWindow() {
+	log.Fatal("Built with the headless tag, there is no window; run headless")
+}
+
+// GameLoop fails, a headless build can't play the game in a window
+func (g *GameInstance) GameLoop() {
+	log.Fatal("Built with the headless tag, there is no window; run headless")
+}
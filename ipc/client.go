@@ -52,8 +52,13 @@ func StartClient(ipcName string, config *ClientConfig) (*Client, error) {
 		} else {
 			cc.encryptionReq = true // defualt is to always enforce encryption
 		}
+
+		cc.socketPath = config.SocketPath
+		cc.tcpAddress = config.TcpAddress
 	}
 
+	cc.socketPath = resolveSocketPath(ipcName, cc.socketPath)
+
 	go startClient(cc)
 
 	return cc, nil
@@ -5,8 +5,10 @@ package ipc
 
 import (
 	"errors"
+	"log"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -15,11 +17,25 @@ import (
 // IpcConnection create a unix socket and start listening connections - for unix and linux
 func (sc *IpcConnection) beginListening() error {
 
-	base := "/tmp/"
-	sock := ".sock"
+	path := sc.socketPath
 
-	if err := os.RemoveAll(base + sc.name + sock); err != nil {
-		return err
+	// Abstract sockets vanish with their listener, file sockets have to be cleaned up - but only
+	// when no other server still owns them
+	if !strings.HasPrefix(path, "@") {
+		lockFile, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			return err
+		}
+
+		if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			lockFile.Close()
+			return errors.New("ipc socket " + path + " is in use by another server")
+		}
+		sc.lockFile = lockFile
+
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
 	}
 
 	var oldUmask int
@@ -27,7 +43,7 @@ func (sc *IpcConnection) beginListening() error {
 		oldUmask = syscall.Umask(0)
 	}
 
-	listen, err := net.Listen("unix", base+sc.name+sock)
+	listen, err := net.Listen("unix", path)
 
 	if sc.unMask == true {
 		syscall.Umask(oldUmask)
@@ -38,12 +54,28 @@ func (sc *IpcConnection) beginListening() error {
 	}
 
 	sc.listen = listen
+	log.Println("Listening on unix socket", path)
+
+	// Clients that can't share the socket directory (eg other containers) connect over tcp instead
+	if sc.tcpHost != "" {
+		tcpListen, err := net.Listen("tcp", net.JoinHostPort(sc.tcpHost, strconv.Itoa(sc.port)))
+		if err != nil {
+			sc.listen.Close()
+			return err
+		}
+
+		sc.tcpListen = tcpListen
+		log.Println("Listening on tcp", tcpListen.Addr().String())
+	}
 
 	sc.status = Listening
 	sc.recieved <- &Message{Status: sc.status.String(), MsgType: -1}
 	sc.connChannel = make(chan bool)
 
-	go sc.acceptLoop()
+	go sc.acceptLoop(sc.listen)
+	if sc.tcpListen != nil {
+		go sc.acceptLoop(sc.tcpListen)
+	}
 
 	err = sc.connectionTimer()
 	if err != nil {
@@ -57,9 +89,6 @@ func (sc *IpcConnection) beginListening() error {
 // Client connect to the unix socket created by the server -  for unix and linux
 func (cc *Client) dial() error {
 
-	base := "/tmp/"
-	sock := ".sock"
-
 	startTime := time.Now()
 
 	for {
@@ -70,7 +99,12 @@ func (cc *Client) dial() error {
 			}
 		}
 
-		conn, err := net.Dial("unix", base+cc.Name+sock)
+		network, address := "unix", cc.socketPath
+		if cc.tcpAddress != "" {
+			network, address = "tcp", cc.tcpAddress
+		}
+
+		conn, err := net.Dial(network, address)
 		if err != nil {
 
 			if strings.Contains(err.Error(), "connect: no such file or directory") == true {
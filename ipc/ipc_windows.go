@@ -2,6 +2,7 @@ package ipc
 
 import (
 	"errors"
+	"log"
 	"net"
 	"strconv"
 	"strings"
@@ -12,21 +13,25 @@ import (
 func (sc *IpcConnection) beginListening() error {
 
 	base := "0.0.0.0"
+	if sc.tcpHost != "" {
+		base = sc.tcpHost
+	}
 	port := strconv.Itoa(sc.port)
 
-	listen, err := net.Listen("tcp4", base+":"+port)
+	listen, err := net.Listen("tcp", net.JoinHostPort(base, port))
 
 	if err != nil {
 		return err
 	}
 
 	sc.listen = listen
+	log.Println("Listening on tcp", listen.Addr().String())
 
 	sc.status = Listening
 	sc.recieved <- &Message{Status: sc.status.String(), MsgType: -1}
 	sc.connChannel = make(chan bool)
 
-	go sc.acceptLoop()
+	go sc.acceptLoop(sc.listen)
 
 	err = sc.connectionTimer()
 	if err != nil {
@@ -40,9 +45,6 @@ func (sc *IpcConnection) beginListening() error {
 // Client connect to the unix socket created by the server -  for unix and linux
 func (cc *Client) dial() error {
 
-	base := "/tmp/"
-	sock := ".sock"
-
 	startTime := time.Now()
 
 	for {
@@ -53,7 +55,12 @@ func (cc *Client) dial() error {
 			}
 		}
 
-		conn, err := net.Dial("unix", base+cc.Name+sock)
+		network, address := "unix", cc.socketPath
+		if cc.tcpAddress != "" {
+			network, address = "tcp", cc.tcpAddress
+		}
+
+		conn, err := net.Dial(network, address)
 		if err != nil {
 
 			if strings.Contains(err.Error(), "connect: no such file or directory") == true {
@@ -0,0 +1,57 @@
+package ipc
+
+// Message types of the game protocol. A request of type N is answered with type N+1,
+// failures are answered with MsgError instead.
+const (
+	MsgPing = 11
+	MsgPong = 12
+
+	MsgReset   = 13
+	MsgResetOk = 14
+
+	MsgBeginControl   = 16
+	MsgControlGranted = 17
+
+	MsgGetObservation = 18
+	MsgObservation    = 19
+
+	MsgStep       = 20
+	MsgStepResult = 21
+
+	MsgResetWithConfig   = 22 // json game.ResetConfig
+	MsgResetWithConfigOk = 23 // json game.ResetConfig that was applied
+
+	MsgSetObservationFormat = 24 // json game.ObservationFormat
+	MsgObservationFormatOk  = 25
+
+	MsgSetResultEncoding = 26 // "json" or "binary"
+	MsgResultEncodingOk  = 27
+
+	// Environment addressed messages carry a 4 byte big endian env id in front of their payload
+	MsgEnvReset          = 30
+	MsgEnvResetOk        = 31
+	MsgEnvStep           = 32
+	MsgEnvStepResult     = 33
+	MsgEnvGetObservation = 34
+	MsgEnvObservation    = 35
+	MsgVectorStep        = 36 // one action byte per environment
+	MsgVectorStepResult  = 37
+	MsgGetEnvCount       = 38
+	MsgEnvCount          = 39
+	MsgGetExpertAction   = 40 // env addressed, asks the autopilot for the runner's next action
+	MsgExpertAction      = 41 // one action byte
+
+	MsgError = 99 // json ErrorReply
+)
+
+// Message types that never go over the wire
+const (
+	MsgStatusChange    = -1 // Message.Status holds the new connection status
+	MsgConnectionError = -2
+)
+
+// ErrorReply - sent as MsgError when a request can't be handled
+type ErrorReply struct {
+	MsgType int // type of the request that failed
+	Error   string
+}
@@ -10,13 +10,64 @@ import (
 	"errors"
 	"gameenv_ai/game"
 	"log"
+	"net"
+	"strconv"
 	"time"
 )
 
 type IpcServer struct {
-	Game       *game.GameInstance
+	Games      []*game.GameInstance // environments hosted by this server, addressed by their index
 	Connection *IpcConnection
 	Config     *ServerConfig
+
+	BinaryResults bool // results are sent in the binary layout, negotiated with MsgSetResultEncoding
+
+	handlers map[int]HandlerFunc
+}
+
+// HandlerFunc - handles a message received by the server
+type HandlerFunc func(i *IpcServer, m *Message)
+
+// Handle - registers the handler for a message type, replacing any previous handler
+func (i *IpcServer) Handle(msgType int, handler HandlerFunc) {
+	if i.handlers == nil {
+		i.handlers = map[int]HandlerFunc{}
+	}
+
+	i.handlers[msgType] = handler
+}
+
+// Dispatch - passes the message to the handler registered for its type.
+// Requests without a handler are answered with a MsgError reply.
+func (i *IpcServer) Dispatch(m *Message) {
+	handler, ok := i.handlers[m.MsgType]
+	if !ok {
+		if m.MsgType > 0 {
+			i.WriteError(m.MsgType, "unknown message type "+strconv.Itoa(m.MsgType))
+		}
+		return
+	}
+
+	handler(i, m)
+}
+
+// WriteError - answers a request with a MsgError reply
+func (i *IpcServer) WriteError(msgType int, reason string) {
+	b, _ := json.Marshal(ErrorReply{MsgType: msgType, Error: reason})
+
+	err := i.Connection.Write(MsgError, b)
+	if err != nil {
+		log.Println("Error writing error reply: ", err)
+	}
+}
+
+// Env - returns the environment with the given id
+func (i *IpcServer) Env(id int) (*game.GameInstance, error) {
+	if id < 0 || id >= len(i.Games) {
+		return nil, errors.New("unknown env id " + strconv.Itoa(id))
+	}
+
+	return i.Games[id], nil
 }
 
 func (i *IpcServer) Start() (*IpcServer, error) {
@@ -64,9 +115,13 @@ func (i *IpcServer) Start() (*IpcServer, error) {
 			sc.unMask = false
 		}
 
+		sc.socketPath = i.Config.SocketPath
+		sc.tcpHost = i.Config.TcpHost
 		sc.port = i.Config.Port
 	}
 
+	sc.socketPath = resolveSocketPath(sc.name, sc.socketPath)
+
 	go func() {
 		err := sc.beginListening()
 		if err != nil {
@@ -79,9 +134,9 @@ func (i *IpcServer) Start() (*IpcServer, error) {
 	return i, err
 }
 
-func (sc *IpcConnection) acceptLoop() {
+func (sc *IpcConnection) acceptLoop(listen net.Listener) {
 	for {
-		conn, err := sc.listen.Accept()
+		conn, err := listen.Accept()
 		if err != nil {
 			break
 		}
@@ -132,6 +187,9 @@ func (sc *IpcConnection) connectionTimer() error {
 			return nil
 		case <-timeout:
 			sc.listen.Close()
+			if sc.tcpListen != nil {
+				sc.tcpListen.Close()
+			}
 			return errors.New("Timed out waiting for client to connect")
 		}
 	}
@@ -349,6 +407,12 @@ func (sc *IpcConnection) Close() {
 
 	sc.status = Closing
 	sc.listen.Close()
+	if sc.tcpListen != nil {
+		sc.tcpListen.Close()
+	}
+	if sc.lockFile != nil {
+		sc.lockFile.Close()
+	}
 	sc.conn.Close()
 
 }
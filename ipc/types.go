@@ -6,12 +6,17 @@ import (
 	"encoding/binary"
 	"errors"
 	"net"
+	"os"
 	"time"
 )
 
 type IpcConnection struct {
 	name        string
 	listen      net.Listener
+	tcpListen   net.Listener
+	tcpHost     string
+	socketPath  string
+	lockFile    *os.File
 	conn        net.Conn
 	status      Status
 	recieved    chan (*Message)
@@ -28,6 +33,8 @@ type IpcConnection struct {
 // Client - holds the details of the client connection and config.
 type Client struct {
 	Name          string
+	socketPath    string
+	tcpAddress    string
 	conn          net.Conn
 	status        Status
 	timeout       float64       //
@@ -80,7 +87,9 @@ type ServerConfig struct {
 	MaxMsgSize        int
 	Encryption        bool
 	UnmaskPermissions bool
-	Port              int
+	SocketPath        string // unix socket path, a leading @ names a linux abstract socket. Defaults to /tmp/<IpcName>.sock
+	TcpHost           string // when set, also listen for tcp connections on TcpHost:Port
+	Port              int    // tcp port, 0 picks a random port
 }
 
 // ClientConfig - used to pass configuation overrides to ClientStart()
@@ -88,6 +97,8 @@ type ClientConfig struct {
 	Timeout    float64
 	RetryTimer time.Duration
 	Encryption bool
+	SocketPath string // unix socket path, a leading @ names a linux abstract socket. Defaults to /tmp/<ipcName>.sock
+	TcpAddress string // host:port - when set the client dials tcp instead of the unix socket
 }
 
 // Encryption - encryption settings
@@ -134,6 +145,17 @@ func checkIpcName(ipcName string) error {
 
 }
 
+// resolves the unix socket path of an ipc name unless a path has been configured
+func resolveSocketPath(ipcName string, socketPath string) string {
+
+	if socketPath != "" {
+		return socketPath
+	}
+
+	return "/tmp/" + ipcName + ".sock"
+
+}
+
 func intToBytes(mLen int) []byte {
 
 	b := make([]byte, 4)
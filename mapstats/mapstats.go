@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gameenv_ai/game"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	generator      = "all"
+	seeds          = 1000
+	firstSeed      = int64(1)
+	size           = game.DefaultMapSize
+	rooms          = 0    // generator default
+	mazeComplexity = -1.0 // generator default
+	strict         = false
+	verbose        = false
+)
+
+var generators = []game.MapGeneratorName{game.GeneratorScatter, game.GeneratorDungeon, game.GeneratorBSP, game.GeneratorMaze, game.GeneratorCaves}
+
+// stats aggregates the reports of one generator
+type stats struct {
+	maps         int
+	problems     int
+	disconnected int
+	pockets      int
+	maxPockets   int
+	floor        int
+	reachable    int
+	deadEnds     int
+	doors        int
+	spawnArea    int
+	elapsed      time.Duration
+}
+
+// Generates maps for many seeds, validates every one of them and prints aggregate statistics per generator.
+// Exits with status 1 when a map is unplayable, or with -strict when a map has unreachable pockets.
+func main() {
+	flag.StringVar(&generator, "generator", generator, "generator to check, all checks every generator")
+	flag.IntVar(&seeds, "seeds", seeds, "maps to generate per generator")
+	flag.Int64Var(&firstSeed, "seed", firstSeed, "seed of the first map, the following maps use the next seeds")
+	flag.IntVar(&size, "size", size, "rows and columns of the maps")
+	flag.IntVar(&rooms, "rooms", rooms, "rooms of the scatter and dungeon generators, 0 keeps their default")
+	flag.Float64Var(&mazeComplexity, "maze-complexity", mazeComplexity, "maze complexity between 0 and 1, negative keeps the default")
+	flag.BoolVar(&strict, "strict", strict, "fail on maps with floor that can't be reached")
+	flag.BoolVar(&verbose, "v", verbose, "print every failing map")
+	flag.Parse()
+
+	if seeds < 1 {
+		log.Fatal("-seeds must be at least 1")
+	}
+
+	difficulty := game.Difficulty{MapSize: size, Rooms: rooms}
+	if mazeComplexity >= 0 {
+		difficulty.MazeComplexity = &mazeComplexity
+	}
+	if err := difficulty.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	names := generators
+	if generator != "all" {
+		names = []game.MapGeneratorName{game.MapGeneratorName(generator)}
+	}
+
+	failed := false
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "generator\tmaps\tproblems\tdisconnected\tpockets\tmax pockets\tfloor\treachable\tdead ends\tdoors\tspawn area\tms/map\t")
+
+	for _, name := range names {
+		g, err := game.NewMapGenerator(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		g = difficulty.Apply(g)
+
+		var s stats
+		for seed := firstSeed; seed < firstSeed+int64(seeds); seed++ {
+			start := time.Now()
+			m := g.Generate(size, size, seed)
+			s.elapsed += time.Since(start)
+
+			r := m.Report()
+			s.add(r)
+
+			unplayable := r.Err() != nil
+			if unplayable || (strict && !r.Connected) {
+				failed = true
+				if verbose {
+					fmt.Fprintf(os.Stderr, "%s seed %d: %s\n", name, seed, describe(r))
+				}
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.2f\t%d\t%.1f\t%.1f%%\t%.1f\t%.1f\t%.1f\t%.2f\t\n",
+			name, s.maps, s.problems, s.disconnected,
+			s.mean(s.pockets), s.maxPockets, s.mean(s.floor), 100*float64(s.reachable)/float64(s.floor),
+			s.mean(s.deadEnds), s.mean(s.doors), s.mean(s.spawnArea),
+			float64(s.elapsed.Microseconds())/1000/float64(s.maps))
+	}
+	w.Flush()
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func (s *stats) add(r game.MapReport) {
+	s.maps++
+	if r.Err() != nil {
+		s.problems++
+	}
+	if !r.Connected {
+		s.disconnected++
+	}
+	s.pockets += r.Pockets
+	if r.Pockets > s.maxPockets {
+		s.maxPockets = r.Pockets
+	}
+	s.floor += r.FloorCells
+	s.reachable += r.ReachableCells
+	s.deadEnds += r.DeadEnds
+	s.doors += r.Doors
+	s.spawnArea += r.SpawnArea
+}
+
+func (s *stats) mean(total int) float64 {
+	return float64(total) / float64(s.maps)
+}
+
+func describe(r game.MapReport) string {
+	if err := r.Err(); err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("%d unreachable floor cells in %d pockets", r.UnreachableCells, r.Pockets)
+}
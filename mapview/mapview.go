@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gameenv_ai/game"
+	"image/png"
+	"log"
+	"os"
+
+	"github.com/faiface/pixel"
+)
+
+var (
+	generator      = string(game.DefaultMapGenerator)
+	seed           = int64(1)
+	size           = game.DefaultMapSize
+	rooms          = 0    // generator default
+	mazeComplexity = -1.0 // generator default
+	doors          = false
+	mapFile        = ""
+	mapDir         = ""
+	episode        = ""
+	out            = "map.png"
+	cellSize       = 8
+)
+
+// Draws a map from above and writes it as PNG: a generated map, a map file, or the map of an episode log
+// with the runner's trajectory and both players' final poses.
+func main() {
+	flag.StringVar(&generator, "generator", generator, "generator of the map")
+	flag.Int64Var(&seed, "seed", seed, "seed of the generated map")
+	flag.IntVar(&size, "size", size, "rows and columns of the generated map")
+	flag.IntVar(&rooms, "rooms", rooms, "rooms of the scatter and dungeon generators, 0 keeps their default")
+	flag.Float64Var(&mazeComplexity, "maze-complexity", mazeComplexity, "maze complexity between 0 and 1, negative keeps the default")
+	flag.BoolVar(&doors, "doors", doors, "hang doors into the doorways of the generated map, like the Doors difficulty parameter")
+	flag.StringVar(&mapFile, "map", mapFile, "draw this map file instead of a generated map, or the map file the episode was played on")
+	flag.StringVar(&mapDir, "map-dir", mapDir, "the map directory the episode was played on, when the server was started with -map-dir")
+	flag.StringVar(&episode, "episode", episode, "draw the map and the trajectory of this episode log")
+	flag.StringVar(&out, "o", out, "output PNG file")
+	flag.IntVar(&cellSize, "cell", cellSize, "pixels per cell")
+	flag.Parse()
+
+	if cellSize < 1 {
+		log.Fatal("-cell must be at least 1")
+	}
+
+	var m *game.Map
+	opts := game.TopDownOptions{CellSize: cellSize}
+
+	switch {
+	case episode != "":
+		episodeLog, err := game.LoadEpisodeLog(episode)
+		if err != nil {
+			log.Fatal("Error loading episode log: ", err)
+		}
+		if m, err = episodeLog.Map(loadMaps()); err != nil {
+			log.Fatal("Error rebuilding the episode's map: ", err)
+		}
+
+		runner, chaser := episodeLog.Runner, episodeLog.Chaser
+		opts.Path = []pixel.Vec{pixel.V(runner.X, runner.Y)}
+		for _, step := range episodeLog.Steps {
+			runner, chaser = step.Runner, step.Chaser
+			opts.Path = append(opts.Path, pixel.V(runner.X, runner.Y))
+		}
+		opts.Visited = opts.Path
+		opts.Runner, opts.Chaser = &runner, &chaser
+	case mapFile != "":
+		var err error
+		if m, err = game.LoadMap(mapFile); err != nil {
+			log.Fatal("Error loading map: ", err)
+		}
+	default:
+		difficulty := game.Difficulty{MapSize: size, Rooms: rooms}
+		if mazeComplexity >= 0 {
+			difficulty.MazeComplexity = &mazeComplexity
+		}
+		if err := difficulty.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		g, err := game.NewMapGenerator(game.MapGeneratorName(generator))
+		if err != nil {
+			log.Fatal(err)
+		}
+		m = difficulty.Apply(g).Generate(size, size, seed)
+		if doors {
+			m.HangDoors()
+		}
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := png.Encode(f, m.RenderTopDown(opts)); err != nil {
+		f.Close()
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Wrote", out)
+}
+
+// loadMaps returns the fixed maps an episode may have been played on
+func loadMaps() []*game.Map {
+	if mapFile != "" {
+		m, err := game.LoadMap(mapFile)
+		if err != nil {
+			log.Fatal("Error loading map: ", err)
+		}
+		return []*game.Map{m}
+	}
+	if mapDir != "" {
+		maps, err := game.LoadMapDir(mapDir)
+		if err != nil {
+			log.Fatal("Error loading maps: ", err)
+		}
+		return maps
+	}
+	return nil
+}
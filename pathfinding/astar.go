@@ -0,0 +1,221 @@
+// Package pathfinding finds shortest paths over the map grid used by the game.
+package pathfinding
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// Connectivity - the moves allowed from a cell
+type Connectivity int
+
+const (
+	FourConnected  Connectivity = iota // up, down, left and right
+	EightConnected                     // also the diagonals
+)
+
+// Options - how the grid is searched
+type Options struct {
+	Connectivity Connectivity
+
+	// CutCorners allows a diagonal move past a single blocked orthogonal neighbour.
+	// A diagonal move between two blocked neighbours is never allowed, and without
+	// CutCorners both orthogonal neighbours have to be walkable.
+	CutCorners bool
+
+	// Walkable reports whether a cell value can be entered, defaults to cell == 0
+	Walkable func(cell int) bool
+}
+
+// Cell - a grid coordinate, indexing mapData[X][Y]
+type Cell struct {
+	X, Y int
+}
+
+// CellOf returns the cell that contains a world position
+func CellOf(p pixel.Vec) Cell {
+	return Cell{int(math.Floor(p.X)), int(math.Floor(p.Y))}
+}
+
+// Center returns the world position of the middle of the cell
+func (c Cell) Center() pixel.Vec {
+	return pixel.V(float64(c.X)+0.5, float64(c.Y)+0.5)
+}
+
+// FindPath returns world space waypoints from start to goal: the centers of the cells along the
+// shortest path, without the start cell, and ending at goal itself. found is false when the goal
+// can't be reached. Start and goal in the same cell yield the single waypoint goal.
+func FindPath(mapData [][]int, start, goal pixel.Vec, opts Options) (waypoints []pixel.Vec, found bool) {
+	cells, found := FindCells(mapData, CellOf(start), CellOf(goal), opts)
+	if !found {
+		return nil, false
+	}
+
+	waypoints = make([]pixel.Vec, 0, len(cells))
+	for i := 1; i < len(cells)-1; i++ {
+		waypoints = append(waypoints, cells[i].Center())
+	}
+	return append(waypoints, goal), true
+}
+
+// FindCells runs A* from start to goal and returns every cell of the shortest path, including
+// both ends. found is false when either end isn't walkable or the goal can't be reached.
+func FindCells(mapData [][]int, start, goal Cell, opts Options) (path []Cell, found bool) {
+	s := search{mapData: mapData, opts: opts, goal: goal}
+	if !s.walkable(start) || !s.walkable(goal) {
+		return nil, false
+	}
+
+	s.nodes = map[Cell]*node{}
+	startNode := &node{cell: start, g: 0, f: s.heuristic(start)}
+	s.nodes[start] = startNode
+	heap.Push(&s.open, startNode)
+
+	for s.open.Len() > 0 {
+		current := heap.Pop(&s.open).(*node)
+		if current.cell == goal {
+			for n := current; n != nil; n = n.parent {
+				path = append(path, n.cell)
+			}
+			reverse(path)
+			return path, true
+		}
+		current.closed = true
+
+		s.expand(current)
+	}
+
+	return nil, false
+}
+
+type node struct {
+	cell   Cell
+	parent *node
+	g, f   float64
+	index  int // position in the open set, -1 once popped
+	closed bool
+}
+
+type search struct {
+	mapData [][]int
+	opts    Options
+	goal    Cell
+	noGoal  bool // searching every reachable cell, see Distances
+	nodes   map[Cell]*node
+	open    openSet
+}
+
+var orthogonal = []Cell{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+var diagonal = []Cell{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+
+func (s *search) expand(current *node) {
+	for _, d := range orthogonal {
+		s.relax(current, Cell{current.cell.X + d.X, current.cell.Y + d.Y}, 1)
+	}
+
+	if s.opts.Connectivity != EightConnected {
+		return
+	}
+
+	for _, d := range diagonal {
+		sideX := s.walkable(Cell{current.cell.X + d.X, current.cell.Y})
+		sideY := s.walkable(Cell{current.cell.X, current.cell.Y + d.Y})
+		if !(sideX && sideY) && !(s.opts.CutCorners && (sideX || sideY)) {
+			continue
+		}
+		s.relax(current, Cell{current.cell.X + d.X, current.cell.Y + d.Y}, math.Sqrt2)
+	}
+}
+
+// relax offers a cheaper route to a neighbour, adding it to the open set when it wasn't seen before
+func (s *search) relax(current *node, c Cell, cost float64) {
+	if !s.walkable(c) {
+		return
+	}
+
+	g := current.g + cost
+	n, seen := s.nodes[c]
+	if !seen {
+		n = &node{cell: c, parent: current, g: g, f: g + s.heuristic(c)}
+		s.nodes[c] = n
+		heap.Push(&s.open, n)
+		return
+	}
+
+	if n.closed || g >= n.g {
+		return
+	}
+
+	n.parent = current
+	n.g = g
+	n.f = g + s.heuristic(c)
+	heap.Fix(&s.open, n.index)
+}
+
+// heuristic is the Manhattan distance on a 4 connected grid and the octile distance on an
+// 8 connected one, neither overestimates the remaining cost
+func (s *search) heuristic(c Cell) float64 {
+	if s.noGoal {
+		return 0
+	}
+
+	dx := math.Abs(float64(c.X - s.goal.X))
+	dy := math.Abs(float64(c.Y - s.goal.Y))
+
+	if s.opts.Connectivity != EightConnected {
+		return dx + dy
+	}
+	return math.Max(dx, dy) + (math.Sqrt2-1)*math.Min(dx, dy)
+}
+
+func (s *search) walkable(c Cell) bool {
+	if c.X < 0 || c.Y < 0 || c.X >= len(s.mapData) || c.Y >= len(s.mapData[c.X]) {
+		return false
+	}
+	if s.opts.Walkable != nil {
+		return s.opts.Walkable(s.mapData[c.X][c.Y])
+	}
+	return s.mapData[c.X][c.Y] == 0
+}
+
+func reverse(path []Cell) {
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+}
+
+// openSet is a min heap of nodes ordered by f, ties broken towards the larger g so the search
+// keeps going along the current path
+type openSet []*node
+
+func (o openSet) Len() int { return len(o) }
+
+func (o openSet) Less(i, j int) bool {
+	if o[i].f == o[j].f {
+		return o[i].g > o[j].g
+	}
+	return o[i].f < o[j].f
+}
+
+func (o openSet) Swap(i, j int) {
+	o[i], o[j] = o[j], o[i]
+	o[i].index = i
+	o[j].index = j
+}
+
+func (o *openSet) Push(x interface{}) {
+	n := x.(*node)
+	n.index = len(*o)
+	*o = append(*o, n)
+}
+
+func (o *openSet) Pop() interface{} {
+	old := *o
+	n := old[len(old)-1]
+	old[len(old)-1] = nil
+	n.index = -1
+	*o = old[:len(old)-1]
+	return n
+}
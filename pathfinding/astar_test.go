@@ -0,0 +1,251 @@
+package pathfinding
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// parseGrid turns rows of '#' (wall) and '.' (floor) into mapData, row i is mapData[i]
+func parseGrid(rows ...string) [][]int {
+	mapData := make([][]int, len(rows))
+	for i, row := range rows {
+		mapData[i] = make([]int, len(row))
+		for j, c := range row {
+			if c == '#' {
+				mapData[i][j] = 1
+			}
+		}
+	}
+	return mapData
+}
+
+func pathCost(path []Cell) float64 {
+	cost := 0.0
+	for i := 1; i < len(path); i++ {
+		if path[i].X != path[i-1].X && path[i].Y != path[i-1].Y {
+			cost += math.Sqrt2
+		} else {
+			cost++
+		}
+	}
+	return cost
+}
+
+// checkPath fails the test when the path doesn't connect start and goal with legal moves
+func checkPath(t *testing.T, mapData [][]int, path []Cell, start, goal Cell, opts Options) {
+	t.Helper()
+
+	if path[0] != start || path[len(path)-1] != goal {
+		t.Fatalf("path runs from %v to %v, expected %v to %v", path[0], path[len(path)-1], start, goal)
+	}
+
+	s := search{mapData: mapData, opts: opts}
+	for i, c := range path {
+		if !s.walkable(c) {
+			t.Fatalf("path enters wall cell %v", c)
+		}
+		if i == 0 {
+			continue
+		}
+
+		dx, dy := c.X-path[i-1].X, c.Y-path[i-1].Y
+		if dx < -1 || dx > 1 || dy < -1 || dy > 1 || (dx == 0 && dy == 0) {
+			t.Fatalf("path jumps from %v to %v", path[i-1], c)
+		}
+		if dx != 0 && dy != 0 {
+			if opts.Connectivity != EightConnected {
+				t.Fatalf("diagonal move from %v to %v on a 4 connected grid", path[i-1], c)
+			}
+			sideX := s.walkable(Cell{path[i-1].X + dx, path[i-1].Y})
+			sideY := s.walkable(Cell{path[i-1].X, path[i-1].Y + dy})
+			if !(sideX && sideY) && !(opts.CutCorners && (sideX || sideY)) {
+				t.Fatalf("path cuts the corner from %v to %v", path[i-1], c)
+			}
+		}
+	}
+}
+
+// dijkstraCost is a brute force reference for the cost of the shortest path, -1 when unreachable
+func dijkstraCost(mapData [][]int, start, goal Cell, opts Options) float64 {
+	s := search{mapData: mapData, opts: opts}
+	dist := map[Cell]float64{start: 0}
+	done := map[Cell]bool{}
+
+	for {
+		var current Cell
+		best := math.Inf(1)
+		for c, d := range dist {
+			if !done[c] && d < best {
+				current, best = c, d
+			}
+		}
+		if math.IsInf(best, 1) {
+			return -1
+		}
+		if current == goal {
+			return best
+		}
+		done[current] = true
+
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				n := Cell{current.X + dx, current.Y + dy}
+				if (dx == 0 && dy == 0) || !s.walkable(n) {
+					continue
+				}
+				cost := 1.0
+				if dx != 0 && dy != 0 {
+					if opts.Connectivity != EightConnected {
+						continue
+					}
+					sideX := s.walkable(Cell{current.X + dx, current.Y})
+					sideY := s.walkable(Cell{current.X, current.Y + dy})
+					if !(sideX && sideY) && !(opts.CutCorners && (sideX || sideY)) {
+						continue
+					}
+					cost = math.Sqrt2
+				}
+				if d, ok := dist[n]; !ok || best+cost < d {
+					dist[n] = best + cost
+				}
+			}
+		}
+	}
+}
+
+func TestFindCellsAroundWall(t *testing.T) {
+	mapData := parseGrid(
+		"#######",
+		"#.....#",
+		"#.###.#",
+		"#.#...#",
+		"#######",
+	)
+
+	path, found := FindCells(mapData, Cell{3, 1}, Cell{3, 3}, Options{Connectivity: FourConnected})
+	if !found {
+		t.Fatal("expected a path")
+	}
+	checkPath(t, mapData, path, Cell{3, 1}, Cell{3, 3}, Options{})
+	if len(path) != 11 {
+		t.Errorf("expected 11 cells, got %d: %v", len(path), path)
+	}
+}
+
+func TestFindCellsUnreachable(t *testing.T) {
+	mapData := parseGrid(
+		"#####",
+		"#.#.#",
+		"#####",
+	)
+
+	if _, found := FindCells(mapData, Cell{1, 1}, Cell{1, 3}, Options{Connectivity: EightConnected, CutCorners: true}); found {
+		t.Error("expected no path through a wall")
+	}
+	if _, found := FindCells(mapData, Cell{1, 1}, Cell{0, 0}, Options{}); found {
+		t.Error("expected no path into a wall")
+	}
+	if _, found := FindCells(mapData, Cell{1, 1}, Cell{9, 9}, Options{}); found {
+		t.Error("expected no path outside the grid")
+	}
+}
+
+func TestCornerCutting(t *testing.T) {
+	mapData := parseGrid(
+		"....",
+		".#..",
+		"....",
+	)
+
+	// from (0,0) to (2,2): the diagonal past the wall at (1,1) is only taken when cutting corners
+	noCut, _ := FindCells(mapData, Cell{0, 0}, Cell{2, 2}, Options{Connectivity: EightConnected})
+	cut, _ := FindCells(mapData, Cell{0, 0}, Cell{2, 2}, Options{Connectivity: EightConnected, CutCorners: true})
+
+	checkPath(t, mapData, noCut, Cell{0, 0}, Cell{2, 2}, Options{Connectivity: EightConnected})
+	checkPath(t, mapData, cut, Cell{0, 0}, Cell{2, 2}, Options{Connectivity: EightConnected, CutCorners: true})
+
+	if pathCost(cut) >= pathCost(noCut) {
+		t.Errorf("cutting corners should be shorter, got %v and %v", pathCost(cut), pathCost(noCut))
+	}
+
+	// two diagonal walls are never squeezed through
+	mapData = parseGrid(
+		".#",
+		"#.",
+	)
+	if _, found := FindCells(mapData, Cell{0, 0}, Cell{1, 1}, Options{Connectivity: EightConnected, CutCorners: true}); found {
+		t.Error("expected no path between two diagonal walls")
+	}
+}
+
+func TestFindCellsIsOptimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, opts := range []Options{
+		{Connectivity: FourConnected},
+		{Connectivity: EightConnected},
+		{Connectivity: EightConnected, CutCorners: true},
+	} {
+		for i := 0; i < 50; i++ {
+			mapData := make([][]int, 16)
+			for x := range mapData {
+				mapData[x] = make([]int, 16)
+				for y := range mapData[x] {
+					if rng.Float64() < 0.3 {
+						mapData[x][y] = 1
+					}
+				}
+			}
+			start := Cell{rng.Intn(16), rng.Intn(16)}
+			goal := Cell{rng.Intn(16), rng.Intn(16)}
+			mapData[start.X][start.Y] = 0
+			mapData[goal.X][goal.Y] = 0
+
+			path, found := FindCells(mapData, start, goal, opts)
+			expected := dijkstraCost(mapData, start, goal, opts)
+
+			if found != (expected >= 0) {
+				t.Fatalf("found = %v, reference cost %v", found, expected)
+			}
+			if !found {
+				continue
+			}
+
+			checkPath(t, mapData, path, start, goal, opts)
+			if math.Abs(pathCost(path)-expected) > 1e-9 {
+				t.Fatalf("path cost %v, shortest is %v", pathCost(path), expected)
+			}
+		}
+	}
+}
+
+func TestFindPathWaypoints(t *testing.T) {
+	mapData := parseGrid(
+		"#####",
+		"#...#",
+		"#####",
+	)
+
+	waypoints, found := FindPath(mapData, pixel.V(1.2, 1.7), pixel.V(1.4, 3.9), Options{})
+	if !found {
+		t.Fatal("expected a path")
+	}
+
+	expected := []pixel.Vec{pixel.V(1.5, 2.5), pixel.V(1.4, 3.9)}
+	if len(waypoints) != len(expected) {
+		t.Fatalf("expected waypoints %v, got %v", expected, waypoints)
+	}
+	for i := range expected {
+		if waypoints[i] != expected[i] {
+			t.Errorf("expected waypoints %v, got %v", expected, waypoints)
+		}
+	}
+
+	waypoints, _ = FindPath(mapData, pixel.V(1.2, 1.7), pixel.V(1.8, 1.1), Options{})
+	if len(waypoints) != 1 || waypoints[0] != pixel.V(1.8, 1.1) {
+		t.Errorf("expected the goal as the only waypoint in the same cell, got %v", waypoints)
+	}
+}
@@ -0,0 +1,30 @@
+package pathfinding
+
+import "container/heap"
+
+// Distances returns the length of the shortest path from start to every cell that can be reached
+// from it, start included at distance 0. Moves follow the same rules as FindCells. The map is
+// empty when start isn't walkable.
+func Distances(mapData [][]int, start Cell, opts Options) map[Cell]float64 {
+	s := search{mapData: mapData, opts: opts, noGoal: true}
+	distances := map[Cell]float64{}
+	if !s.walkable(start) {
+		return distances
+	}
+
+	// without a goal the heuristic is 0 and the search is Dijkstra's, every popped cell is final
+	s.nodes = map[Cell]*node{}
+	startNode := &node{cell: start}
+	s.nodes[start] = startNode
+	heap.Push(&s.open, startNode)
+
+	for s.open.Len() > 0 {
+		current := heap.Pop(&s.open).(*node)
+		current.closed = true
+		distances[current.cell] = current.g
+
+		s.expand(current)
+	}
+
+	return distances
+}
@@ -0,0 +1,39 @@
+package pathfinding
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistances(t *testing.T) {
+	mapData := parseGrid(
+		"#######",
+		"#...#.#",
+		"#.#.#.#",
+		"#.....#",
+		"#######",
+	)
+
+	for _, opts := range []Options{{}, {Connectivity: EightConnected}} {
+		start := Cell{1, 1}
+		distances := Distances(mapData, start, opts)
+
+		for x := range mapData {
+			for y := range mapData[x] {
+				c := Cell{x, y}
+				path, found := FindCells(mapData, start, c, opts)
+				d, reached := distances[c]
+				if found != reached {
+					t.Fatalf("%+v: %v reached = %v, but a path found = %v", opts, c, reached, found)
+				}
+				if found && math.Abs(d-pathCost(path)) > 1e-9 {
+					t.Fatalf("%+v: distance to %v is %v, the shortest path costs %v", opts, c, d, pathCost(path))
+				}
+			}
+		}
+	}
+
+	if len(Distances(mapData, Cell{0, 0}, Options{})) != 0 {
+		t.Fatal("expected no distances from a wall")
+	}
+}
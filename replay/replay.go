@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gameenv_ai/game"
+	"image"
+	"log"
+	"os"
+)
+
+var (
+	frameDir = ""
+	window   = false
+	fps      = 30.0
+	mapFile  = ""
+	mapDir   = ""
+)
+
+// Re-simulates episode logs written with the server's -episode-log-dir option and verifies that
+// every reward, done flag and pose matches the recording. Exits with status 1 on any mismatch.
+// Run from the directory containing the assets folder.
+func main() {
+	flag.StringVar(&frameDir, "frames", frameDir, "write the runner's view after every step as PNG into this directory")
+	flag.BoolVar(&window, "window", window, "show the replay in a window")
+	flag.Float64Var(&fps, "fps", fps, "replay speed in the window")
+	flag.StringVar(&mapFile, "map", mapFile, "the map file the episodes were played on, when the server was started with -map")
+	flag.StringVar(&mapDir, "map-dir", mapDir, "the map directory the episodes were played on, when the server was started with -map-dir")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: replay [flags] episode.json...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var maps []*game.Map
+	if mapFile != "" {
+		m, err := game.LoadMap(mapFile)
+		if err != nil {
+			log.Fatal("Error loading map: ", err)
+		}
+		maps = []*game.Map{m}
+	} else if mapDir != "" {
+		var err error
+		if maps, err = game.LoadMapDir(mapDir); err != nil {
+			log.Fatal("Error loading maps: ", err)
+		}
+	}
+
+	ok := true
+	run := func() {
+		var onFrame func(step int, f *image.RGBA)
+		if window {
+			onFrame = newWindow()
+		}
+
+		for _, path := range flag.Args() {
+			ok = replay(path, maps, onFrame) && ok
+		}
+	}
+
+	if window {
+		game.RunWindow(run)
+	} else {
+		run()
+	}
+
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+func replay(path string, maps []*game.Map, onFrame func(step int, f *image.RGBA)) bool {
+	episodeLog, err := game.LoadEpisodeLog(path)
+	if err != nil {
+		log.Println("Error loading episode log: ", err)
+		return false
+	}
+
+	opts := game.ReplayOptions{OnFrame: onFrame, Maps: maps}
+	if frameDir != "" && flag.NArg() > 1 {
+		opts.FrameDir = fmt.Sprintf("%s/%d", frameDir, *episodeLog.Config.Seed)
+	} else {
+		opts.FrameDir = frameDir
+	}
+
+	report, err := game.Replay(episodeLog, opts)
+	if err != nil {
+		log.Println("Error replaying episode: ", err)
+		return false
+	}
+
+	for _, m := range report.Mismatches {
+		fmt.Println(path, m)
+	}
+	fmt.Printf("%s: replayed %d/%d steps, %d mismatches\n", path, report.Steps, len(episodeLog.Steps), len(report.Mismatches))
+
+	return len(report.Mismatches) == 0
+}
@@ -0,0 +1,36 @@
+//go:build !headless
+
+package main
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"image"
+	"log"
+	"os"
+	"time"
+)
+
+// newWindow opens a window and returns a frame callback that shows frames at the replay speed
+func newWindow() func(step int, f *image.RGBA) {
+	win, err := pixelgl.NewWindow(pixelgl.WindowConfig{
+		Title:  "wolf3d replay",
+		Bounds: pixel.R(0, 0, 640, 480),
+		VSync:  true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return func(step int, f *image.RGBA) {
+		if win.Closed() {
+			os.Exit(0)
+		}
+
+		p := pixel.PictureDataFromImage(f)
+		pixel.NewSprite(p, p.Bounds()).Draw(win, pixel.IM.Moved(win.Bounds().Center()))
+		win.Update()
+
+		time.Sleep(time.Duration(float64(time.Second) / fps))
+	}
+}
@@ -0,0 +1,14 @@
+//go:build headless
+
+package main
+
+import (
+	"image"
+	"log"
+)
+
+// newWindow fails, a headless build has no window to show the replay in
+func newWindow() func(step int, f *image.RGBA) {
+	log.Fatal("Built with the headless tag, there is no window; replay without -window")
+	return nil
+}
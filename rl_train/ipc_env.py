@@ -1,12 +1,14 @@
 import base64
 import io
 import json
+import os
 import time
 
 import gym
 import numpy
 import numpy as np
 import socket
+import struct
 import cv2
 import pandas
 from gym.utils import seeding
@@ -14,13 +16,24 @@ from gym import utils
 
 
 class GameIpcEnv(gym.Env, utils.EzPickle):
-    def __init__(self):
+    def __init__(self, obs_encoding="jpeg", obs_quality=60, binary_results=False, tcp_address=None, socket_path=None, top_down=False):
         utils.EzPickle.__init__(self)
+        # unix socket of the game server, a leading @ names a linux abstract socket
+        self.socket_path = socket_path or os.environ.get("WOLF3D_IPC_SOCKET", "/tmp/wolf3d_ipc_player.sock")
+        # "host:port" of the game server's tcp listener, the unix socket is used when None
+        self.tcp_address = tcp_address
+        # use the compact binary step result layout instead of json
+        self.binary_results = binary_results
+        # observation encoding negotiated with the game: jpeg, png, rgb (raw) or gray (raw)
+        self.obs_encoding = obs_encoding
+        self.obs_quality = obs_quality
+        # add the top-down view of the map as extra channels, needs the rgb or gray encoding
+        self.top_down = top_down
         self.valueBuffer = []
         self._seed(seed=time.time_ns())
         self.episodeNumber = 0
         self.is_connected = None
-        self.action_space = gym.spaces.Discrete(7)
+        self.action_space = gym.spaces.Discrete(8)
         self.IMG_WIDTH = 128
         self.IMG_HEIGHT = 128
         self.num_envs = 1
@@ -29,15 +42,46 @@ class GameIpcEnv(gym.Env, utils.EzPickle):
         self.pos_mean = 0
         self.pos_std = 0
 
+        obs_channels = 3
+        if top_down:
+            obs_channels = 2 if obs_encoding == "gray" else 6
+
         self.observation_space = gym.spaces.Dict(
-            obs1 = gym.spaces.Box(low=0, high=1, shape=(3, self.IMG_WIDTH, self.IMG_HEIGHT), dtype=np.float32),
+            obs1 = gym.spaces.Box(low=0, high=1, shape=(obs_channels, self.IMG_WIDTH, self.IMG_HEIGHT), dtype=np.float32),
             obs2 = gym.spaces.Box(low=0, high=1, shape=(9,), dtype=np.float32))
         self.connect()
 
-    def reset(self):
+    def reset(self, seed=None, chaser=None, map=None, generator=None, dungeon=None, difficulty=None):
         #print("reset")
         print(f"Cur min/max/mean/std: {self.pos_min} / {self.pos_max} / {self.pos_mean} / {self.pos_std}")
 
+        if seed is not None or chaser is not None or map is not None or generator is not None or dungeon is not None or difficulty is not None:
+            # reset into a reproducible episode, the same seed always yields the same map and spawns
+            config = {}
+            if seed is not None:
+                config["Seed"] = int(seed)
+            if chaser is not None:
+                # scripted chaser behaviour for this episode, e.g. "shortest_path"
+                config["ChaserBehaviour"] = chaser
+            if map is not None:
+                # name of a map file loaded with -map or -map-dir, e.g. "arena.json"
+                config["Map"] = map
+            if generator is not None:
+                # map generator for this episode, e.g. "maze"
+                config["Generator"] = generator
+            if dungeon is not None:
+                # rooms and corridors for this episode, e.g. {"Rooms": 4, "CorridorWidth": 2}
+                config["Dungeon"] = dungeon
+            if difficulty is not None:
+                # curriculum parameters, e.g. {"MapSize": 24, "MinSpawnDistance": 5, "MaxSpawnDistance": 10}
+                config["Difficulty"] = difficulty
+            self.sendMessage(22, json.dumps(config).encode("utf-8"))
+            msgType, msgData = self.readMessageReply()
+            if msgType == 23:
+                return self.get_observation()
+
+            return None, None
+
         self.sendMessage(13, b"reset")
         msgType, msgData = self.readMessageReply()
         if msgType == 14:
@@ -59,6 +103,7 @@ class GameIpcEnv(gym.Env, utils.EzPickle):
             4: "STRATE_RIGHT",
             5: "TURN_LEFT",
             6: "TURN_RIGHT",
+            7: "USE",
         }
         return [ACTION_MEANING[i] for i in range(0, self.action_space.n)]
 
@@ -68,14 +113,9 @@ class GameIpcEnv(gym.Env, utils.EzPickle):
         if actionResult is None:
             return None, 0.0, True, {}
 
-        obs1 = actionResult['Observation']
+        obs1 = self.imgFromReply(actionResult)
         obs2 = actionResult['Observation_Pos']
 
-        # base64 decode
-        obs1 = base64.b64decode(obs1)
-
-        obs1 = self.imgFromStream(obs1)
-
         obs = dict()
 
         obs['obs1'] = obs1
@@ -101,9 +141,18 @@ class GameIpcEnv(gym.Env, utils.EzPickle):
         print("render")
 
     def connect(self):
-        # Connect via unix socket to game process
-        self.sock = socket.socket(socket.AF_UNIX, socket.SOCK_STREAM)
-        self.sock.connect("/tmp/wolf3d_ipc_player.sock")
+        if self.tcp_address:
+            # Connect via tcp, eg when the game runs in another container
+            host, port = self.tcp_address.rsplit(":", 1)
+            self.sock = socket.create_connection((host, int(port)))
+        else:
+            # Connect via unix socket to game process
+            path = self.socket_path
+            if path.startswith("@"):
+                path = "\0" + path[1:]
+
+            self.sock = socket.socket(socket.AF_UNIX, socket.SOCK_STREAM)
+            self.sock.connect(path)
 
         # if on windows used a named pipe instead
         #self.sock = socket.socket(socket.AF_INET, socket.SOCK_STREAM)
@@ -124,6 +173,30 @@ class GameIpcEnv(gym.Env, utils.EzPickle):
         if status:
             msgType, msgData = self.readMessageReply()
 
+        self.setObservationFormat(self.obs_encoding, self.obs_quality)
+
+        if self.binary_results:
+            self.sendMessage(26, b"binary")
+            msgType, msgData = self.readMessageReply()
+            if msgType != 27 or msgData != "encoding ok":
+                print(f"Binary results rejected: {msgData}")
+                self.binary_results = False
+
+    def setObservationFormat(self, encoding, quality=60):
+        fmt = {"Encoding": encoding}
+        if encoding == "jpeg":
+            fmt["Quality"] = quality
+        if self.top_down:
+            fmt["TopDown"] = True
+
+        self.sendMessage(24, json.dumps(fmt).encode("utf-8"))
+        msgType, msgData = self.readMessageReply()
+        if msgType != 25 or msgData != "format ok":
+            print(f"Observation format rejected: {msgData}")
+            return False
+
+        return True
+
 
     def performHandshake(self):
         status = self.readIpcHandshake()
@@ -308,10 +381,8 @@ class GameIpcEnv(gym.Env, utils.EzPickle):
         if success:
             msgType, msgData = self.readMessageReplyBytes()
             if msgType == 19:
-                msgReplyObj = json.loads(msgData)
-                # base64 decode
-                obs = base64.b64decode(msgReplyObj['Observation'])
-                img = self.imgFromStream(obs)
+                msgReplyObj = self.parseResult(msgData)
+                img = self.imgFromReply(msgReplyObj)
 
                 obs1 = img
                 obs2 = msgReplyObj['Observation_Pos']
@@ -377,16 +448,88 @@ class GameIpcEnv(gym.Env, utils.EzPickle):
 
         msgType, msgReply = self.readMessageReplyBytes()
         if msgReply:
-            msgReplyObj = json.loads(msgReply)
+            msgReplyObj = self.parseResult(msgReply)
             #print(f"Action reply: {msgReplyObj}")
             return msgReplyObj
         else:
             return None
 
 
+    def expert_action(self, env_id=0):
+        # the autopilot's action for the runner's current pose, used as a demonstration label
+        self.sendMessage(40, struct.pack(">I", env_id))
+
+        msgType, msgReply = self.readMessageReplyBytes()
+        if msgType == 41 and msgReply:
+            return msgReply[0]
+        return None
+
+    def parseResult(self, data):
+        if not self.binary_results:
+            return json.loads(data)
+
+        # see game/resultbinary.go for the layout
+        magic, version, flags, reward, vecLen = struct.unpack_from(">2sBBfI", data, 0)
+        if magic != b"WR" or version != 1:
+            raise ValueError("not a binary step result")
+        offset = 12
+        vector = list(struct.unpack_from(f">{vecLen}f", data, offset))
+        offset += 4 * vecLen
+        encoding, width, height, channels, imgLen = struct.unpack_from(">BHHBI", data, offset)
+        offset += 10
+        image = bytes(data[offset:offset + imgLen])
+        offset += imgLen
+        infoLen, = struct.unpack_from(">H", data, offset)
+        info = bytes(data[offset + 2:offset + 2 + infoLen]).decode("utf-8")
+
+        return {
+            'Reward': reward,
+            'Observation': image,
+            'Observation_Pos': vector,
+            'Done': bool(flags & 1),
+            'Truncated': bool(flags & 2),
+            'Info': info,
+            'Encoding': ["", "jpeg", "png", "rgb", "gray"][encoding],
+            'Width': width,
+            'Height': height,
+            'Channels': channels,
+        }
+
     def setMaxMessageLength(self, maxLen):
         self.maxMessageLen = maxLen
 
+    def imgFromReply(self, reply):
+        data = reply['Observation']
+        if isinstance(data, str):
+            data = base64.b64decode(data)
+        encoding = reply.get('Encoding', 'jpeg')
+
+        if encoding in ('jpeg', 'png'):
+            return self.imgFromStream(data)
+
+        # raw frames carry their shape in the reply
+        from PIL import Image
+        pix = numpy.frombuffer(data, numpy.uint8).reshape(reply['Height'], reply['Width'], reply['Channels'])
+        if reply['Channels'] == 1:
+            img = Image.fromarray(pix[:, :, 0], mode='L')
+        elif reply['Channels'] == 3:
+            img = Image.fromarray(pix, mode='RGB')
+        else:
+            # the top-down view adds channels PIL has no mode for, resize them one by one
+            planes = [Image.fromarray(numpy.ascontiguousarray(pix[:, :, c]), mode='L').resize((self.IMG_WIDTH, self.IMG_HEIGHT))
+                      for c in range(reply['Channels'])]
+            img = numpy.stack([numpy.array(p) for p in planes], axis=-1)
+
+        if isinstance(img, Image.Image):
+            img = img.resize((self.IMG_WIDTH, self.IMG_HEIGHT))
+
+        pix = numpy.array(img).astype(numpy.float32)
+
+        # normalize to 0-1
+        img = pix / 255.0
+
+        return img.reshape(reply['Channels'], self.IMG_WIDTH, self.IMG_HEIGHT)
+
     def imgFromStream(self, msgData):
         # jpeg decompress msgData into numpy array
         # img = cv2.imdecode(np.frombuffer(msgData, np.uint8), cv2.IMREAD_COLOR)
@@ -1,5 +1,7 @@
 import base64
+import glob
 import json
+import os
 
 import PIL
 import numpy as np
@@ -17,21 +19,23 @@ useAccelerator = True
 IMG_WIDTH = 128
 IMG_HEIGHT = 128
 
-def load_text_file(filename, device):
+def load_dataset(dirname, device):
+    # reads every chunk of a dataset recorded with the server's -record-dir option
     sampleCount = 0
     samples = []
-    with open('../' + filename, 'r') as f:
-        for line in f:
-            sampleCount += 1
-            try:
-                samples.append(json.loads(line))
-            except Exception as e:
-                print(f'Error on line {sampleCount}: {e}')
-                pass
+    for filename in sorted(glob.glob(os.path.join('..', dirname, 'chunk-*.jsonl'))):
+        with open(filename, 'r') as f:
+            for line in f:
+                sampleCount += 1
+                try:
+                    samples.append(json.loads(line))
+                except Exception as e:
+                    print(f'Error on line {sampleCount}: {e}')
+                    pass
     # Create an instance of the dataset
     dataset = ObservationDataset(samples, device)
 
-    print(f'Loaded {len(dataset)} samples from {filename}')
+    print(f'Loaded {len(dataset)} samples from {dirname}')
 
     return dataset
 
@@ -88,8 +92,8 @@ class ObservationDataset(Dataset):
         observation = self.data[idx]
 
         # convert textobs and imgobs to tensors
-        textobs = torch.tensor(np.array(observation["Obs1"]), dtype=torch.float32, device=device)
-        imgobs = torch.tensor(asimg(observation['Obs2']), dtype=torch.float32, device=device)
+        textobs = torch.tensor(np.array(observation["Observation_Pos"]), dtype=torch.float32, device=device)
+        imgobs = torch.tensor(asimg(observation['Observation']), dtype=torch.float32, device=device)
         actions = torch.tensor(observation["Action"], dtype=torch.long, device=device)
         labels = actions # torch.LongTensor(actions, device=device)
 
@@ -242,8 +246,8 @@ weight_decay = 1e-4
 optimizer = torch.optim.Adam(model.parameters(), lr=1e-3)
 loss_fn = torch.nn.CrossEntropyLoss().to(device)
 
-data_loader = DataLoader(load_text_file("train_data.txt", device), batch_size=batch_size, shuffle=True)
-data_loader_test = DataLoader(load_text_file("test_data.txt", device), batch_size=batch_size, shuffle=True)
+data_loader = DataLoader(load_dataset("recordings/train", device), batch_size=batch_size, shuffle=True)
+data_loader_test = DataLoader(load_dataset("recordings/test", device), batch_size=batch_size, shuffle=True)
 
 
 def train_batch(dl, model, loss_fn, optimizer, stats):
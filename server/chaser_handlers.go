@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"gameenv_ai/game"
+	"gameenv_ai/ipc"
+)
+
+// registerChaserHandlers sets up the endpoint a second agent uses to drive the chaser of env 0.
+// The runner owns the episode, the chaser only steps and observes, and both are stepped in lockstep.
+func registerChaserHandlers(sc *ipc.IpcServer) {
+	sc.Handle(ipc.MsgStatusChange, handleChaserStatusChange)
+	sc.Handle(ipc.MsgPing, handlePing)
+	sc.Handle(ipc.MsgReset, handleChaserReset)
+	sc.Handle(ipc.MsgResetWithConfig, handleChaserResetWithConfig)
+	sc.Handle(ipc.MsgBeginControl, handleChaserBeginControl)
+	sc.Handle(ipc.MsgGetObservation, handleChaserGetObservation)
+	sc.Handle(ipc.MsgStep, handleChaserStep)
+	sc.Handle(ipc.MsgSetObservationFormat, handleChaserSetObservationFormat)
+	sc.Handle(ipc.MsgSetResultEncoding, handleSetResultEncoding)
+}
+
+// The chaser goes back to the local controller as soon as its agent disconnects,
+// so a runner waiting on it is never blocked for good
+func handleChaserStatusChange(sc *ipc.IpcServer, m *ipc.Message) {
+	switch m.Status {
+	case "Connected":
+		sc.Games[0].SetChaserObservationFormat(game.DefaultObservationFormat)
+		sc.BinaryResults = false
+	case "Re-connecting", "Timeout", "Closed":
+		sc.Games[0].AttachChaser(false)
+	}
+}
+
+// Resets are driven by the runner, the chaser is only told they are fine
+func handleChaserReset(sc *ipc.IpcServer, m *ipc.Message) {
+	sc.Connection.Write(ipc.MsgResetOk, []byte("reset ok"))
+}
+
+func handleChaserResetWithConfig(sc *ipc.IpcServer, m *ipc.Message) {
+	seed := sc.Games[0].EpisodeSeed()
+	writeJson(sc, ipc.MsgResetWithConfigOk, game.ResetConfig{Seed: &seed})
+}
+
+func handleChaserBeginControl(sc *ipc.IpcServer, m *ipc.Message) {
+	sc.Games[0].AttachChaser(true)
+	sc.Connection.Write(ipc.MsgControlGranted, []byte("control granted"))
+}
+
+func handleChaserGetObservation(sc *ipc.IpcServer, m *ipc.Message) {
+	writeResult(sc, ipc.MsgObservation, sc.Games[0].ChaserObservationResult())
+}
+
+func handleChaserStep(sc *ipc.IpcServer, m *ipc.Message) {
+	if len(m.Data) < 1 {
+		sc.WriteError(m.MsgType, "missing action")
+		return
+	}
+	if game.RLAction(m.Data[0]) > game.RLActionUse {
+		sc.WriteError(m.MsgType, "unknown action")
+		return
+	}
+
+	writeResult(sc, ipc.MsgStepResult, sc.Games[0].Step(game.RoleChaser, game.RLAction(m.Data[0])))
+}
+
+func handleChaserSetObservationFormat(sc *ipc.IpcServer, m *ipc.Message) {
+	var format game.ObservationFormat
+	if err := json.Unmarshal(m.Data, &format); err != nil {
+		sc.WriteError(m.MsgType, err.Error())
+		return
+	}
+	if err := sc.Games[0].SetChaserObservationFormat(format); err != nil {
+		sc.WriteError(m.MsgType, err.Error())
+		return
+	}
+	sc.Connection.Write(ipc.MsgObservationFormatOk, []byte("format ok"))
+}
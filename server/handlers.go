@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gameenv_ai/game"
+	"gameenv_ai/ipc"
+	"sync"
+)
+
+func registerPlayerHandlers(sc *ipc.IpcServer) {
+	sc.Handle(ipc.MsgStatusChange, handleStatusChange)
+	sc.Handle(ipc.MsgPing, handlePing)
+	sc.Handle(ipc.MsgReset, handleReset)
+	sc.Handle(ipc.MsgResetWithConfig, handleResetWithConfig)
+	sc.Handle(ipc.MsgBeginControl, handleBeginControl)
+	sc.Handle(ipc.MsgGetObservation, handleGetObservation)
+	sc.Handle(ipc.MsgStep, handleStep)
+	sc.Handle(ipc.MsgSetObservationFormat, handleSetObservationFormat)
+	sc.Handle(ipc.MsgSetResultEncoding, handleSetResultEncoding)
+	sc.Handle(ipc.MsgEnvReset, handleEnvReset)
+	sc.Handle(ipc.MsgEnvStep, handleEnvStep)
+	sc.Handle(ipc.MsgEnvGetObservation, handleEnvGetObservation)
+	sc.Handle(ipc.MsgVectorStep, handleVectorStep)
+	sc.Handle(ipc.MsgGetEnvCount, handleGetEnvCount)
+	sc.Handle(ipc.MsgGetExpertAction, handleGetExpertAction)
+}
+
+// A new connection starts out with the default observation format and json results
+func handleStatusChange(sc *ipc.IpcServer, m *ipc.Message) {
+	if m.Status == "Connected" {
+		for _, env := range sc.Games {
+			env.SetObservationFormat(game.DefaultObservationFormat)
+		}
+		sc.BinaryResults = false
+	}
+}
+
+func handlePing(sc *ipc.IpcServer, m *ipc.Message) {
+	sc.Connection.Write(ipc.MsgPong, []byte("pong"))
+}
+
+func handleReset(sc *ipc.IpcServer, m *ipc.Message) {
+	sc.Games[0].Reset()
+	sc.Connection.Write(ipc.MsgResetOk, []byte("reset ok"))
+}
+
+func handleResetWithConfig(sc *ipc.IpcServer, m *ipc.Message) {
+	var cfg game.ResetConfig
+	if err := json.Unmarshal(m.Data, &cfg); err != nil {
+		sc.WriteError(m.MsgType, "invalid reset config: "+err.Error())
+		return
+	}
+	if err := sc.Games[0].CheckResetConfig(cfg); err != nil {
+		sc.WriteError(m.MsgType, "invalid reset config: "+err.Error())
+		return
+	}
+
+	writeJson(sc, ipc.MsgResetWithConfigOk, sc.Games[0].ResetWithConfig(cfg))
+}
+
+func handleBeginControl(sc *ipc.IpcServer, m *ipc.Message) {
+	sc.Connection.Write(ipc.MsgControlGranted, []byte("control granted"))
+}
+
+func handleGetObservation(sc *ipc.IpcServer, m *ipc.Message) {
+	writeResult(sc, ipc.MsgObservation, sc.Games[0].ObservationResult())
+}
+
+func handleStep(sc *ipc.IpcServer, m *ipc.Message) {
+	if len(m.Data) < 1 {
+		sc.WriteError(m.MsgType, "missing action")
+		return
+	}
+	if game.RLAction(m.Data[0]) > game.RLActionUse {
+		sc.WriteError(m.MsgType, "unknown action")
+		return
+	}
+
+	writeResult(sc, ipc.MsgStepResult, sc.Games[0].Step(game.RoleRunner, game.RLAction(m.Data[0])))
+}
+
+// Negotiate the observation encoding for this connection, applies to every hosted environment
+func handleSetObservationFormat(sc *ipc.IpcServer, m *ipc.Message) {
+	var format game.ObservationFormat
+	if err := json.Unmarshal(m.Data, &format); err != nil {
+		sc.WriteError(m.MsgType, err.Error())
+		return
+	}
+	if err := format.Validate(); err != nil {
+		sc.WriteError(m.MsgType, err.Error())
+		return
+	}
+
+	for _, env := range sc.Games {
+		env.SetObservationFormat(format)
+	}
+	sc.Connection.Write(ipc.MsgObservationFormatOk, []byte("format ok"))
+}
+
+// Negotiate how step and observation results are serialized for this connection
+func handleSetResultEncoding(sc *ipc.IpcServer, m *ipc.Message) {
+	switch string(m.Data) {
+	case "json":
+		sc.BinaryResults = false
+	case "binary":
+		sc.BinaryResults = true
+	default:
+		sc.WriteError(m.MsgType, "unknown result encoding")
+		return
+	}
+	sc.Connection.Write(ipc.MsgResultEncodingOk, []byte("encoding ok"))
+}
+
+func handleEnvReset(sc *ipc.IpcServer, m *ipc.Message) {
+	env, data, err := envFromMessage(sc, m)
+	if err != nil {
+		sc.WriteError(m.MsgType, err.Error())
+		return
+	}
+
+	var cfg game.ResetConfig
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			sc.WriteError(m.MsgType, "invalid reset config: "+err.Error())
+			return
+		}
+	}
+	if err := env.CheckResetConfig(cfg); err != nil {
+		sc.WriteError(m.MsgType, "invalid reset config: "+err.Error())
+		return
+	}
+
+	writeJson(sc, ipc.MsgEnvResetOk, env.ResetWithConfig(cfg))
+}
+
+func handleEnvStep(sc *ipc.IpcServer, m *ipc.Message) {
+	env, data, err := envFromMessage(sc, m)
+	if err != nil {
+		sc.WriteError(m.MsgType, err.Error())
+		return
+	}
+	if len(data) < 1 {
+		sc.WriteError(m.MsgType, "missing action")
+		return
+	}
+	if game.RLAction(data[0]) > game.RLActionUse {
+		sc.WriteError(m.MsgType, "unknown action")
+		return
+	}
+
+	writeResult(sc, ipc.MsgEnvStepResult, env.Step(game.RoleRunner, game.RLAction(data[0])))
+}
+
+func handleEnvGetObservation(sc *ipc.IpcServer, m *ipc.Message) {
+	env, _, err := envFromMessage(sc, m)
+	if err != nil {
+		sc.WriteError(m.MsgType, err.Error())
+		return
+	}
+
+	writeResult(sc, ipc.MsgEnvObservation, env.ObservationResult())
+}
+
+// Vectorized step, one action byte per environment in env id order
+func handleVectorStep(sc *ipc.IpcServer, m *ipc.Message) {
+	if len(m.Data) != len(sc.Games) {
+		sc.WriteError(m.MsgType, fmt.Sprintf("expected %d actions, got %d", len(sc.Games), len(m.Data)))
+		return
+	}
+	for i, action := range m.Data {
+		if game.RLAction(action) > game.RLActionUse {
+			sc.WriteError(m.MsgType, fmt.Sprintf("unknown action for env %d", i))
+			return
+		}
+	}
+
+	results := make([]game.RLActionResult, len(sc.Games))
+	var wg sync.WaitGroup
+	for i, env := range sc.Games {
+		wg.Add(1)
+		go func(i int, env *game.GameInstance) {
+			defer wg.Done()
+			results[i] = env.Step(game.RoleRunner, game.RLAction(m.Data[i]))
+		}(i, env)
+	}
+	wg.Wait()
+
+	writeResults(sc, ipc.MsgVectorStepResult, results)
+}
+
+func handleGetEnvCount(sc *ipc.IpcServer, m *ipc.Message) {
+	sc.Connection.Write(ipc.MsgEnvCount, intToBytes(len(sc.Games)))
+}
+
+// The autopilot's action is an expert label for the current pose, the environment isn't stepped
+func handleGetExpertAction(sc *ipc.IpcServer, m *ipc.Message) {
+	env, _, err := envFromMessage(sc, m)
+	if err != nil {
+		sc.WriteError(m.MsgType, err.Error())
+		return
+	}
+
+	sc.Connection.Write(ipc.MsgExpertAction, []byte{byte(env.ExpertAction())})
+}
+
+// envFromMessage splits the 4 byte big endian environment id off the front of an env addressed message
+func envFromMessage(sc *ipc.IpcServer, m *ipc.Message) (*game.GameInstance, []byte, error) {
+	if len(m.Data) < 4 {
+		return nil, nil, errors.New("missing env id")
+	}
+
+	env, err := sc.Env(int(binary.BigEndian.Uint32(m.Data[:4])))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return env, m.Data[4:], nil
+}
+
+func writeJson(sc *ipc.IpcServer, msgType int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		fmt.Println("Error serializing reply: ", err)
+		return
+	}
+
+	if err := sc.Connection.Write(msgType, b); err != nil {
+		fmt.Println("Error writing reply: ", err)
+	}
+}
+
+// writeResult replies with a step or observation result in the encoding negotiated for this connection
+func writeResult(sc *ipc.IpcServer, msgType int, result game.RLActionResult) {
+	if !sc.BinaryResults {
+		writeJson(sc, msgType, result)
+		return
+	}
+
+	b, err := result.MarshalBinary()
+	if err != nil {
+		fmt.Println("Error serializing result: ", err)
+		return
+	}
+
+	if err := sc.Connection.Write(msgType, b); err != nil {
+		fmt.Println("Error writing result message: ", err)
+	}
+}
+
+func writeResults(sc *ipc.IpcServer, msgType int, results []game.RLActionResult) {
+	if !sc.BinaryResults {
+		writeJson(sc, msgType, results)
+		return
+	}
+
+	b, err := game.MarshalBinaryResults(results)
+	if err != nil {
+		fmt.Println("Error serializing results: ", err)
+		return
+	}
+
+	if err := sc.Connection.Write(msgType, b); err != nil {
+		fmt.Println("Error writing result message: ", err)
+	}
+}
+
+func intToBytes(i int) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(i))
+	return b
+}
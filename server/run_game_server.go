@@ -5,8 +5,9 @@ import (
 	"fmt"
 	"gameenv_ai/game"
 	"gameenv_ai/ipc"
-	"github.com/faiface/pixel/pixelgl"
 	"log"
+	"path/filepath"
+	"time"
 )
 
 var (
@@ -14,40 +15,227 @@ var (
 	width      = 320
 	height     = 240
 	scale      = 3.0
-	port       = 0 // random
+	port       = -1 // tcp disabled, 0 picks a random port
+	host       = "127.0.0.1"
+	socketPath = "" // defaults to /tmp/wolf3d_ipc_player.sock
+	headless   = false
+	seed       = int64(0) // random
+	envs       = 1
+	chaser     = string(game.DefaultChaserBehaviour)
+	autopilot  = false
+	lighting   = false
+	shading    = false
+	doors      = false
+
+	recordDir        = "" // recording disabled
+	recordChunkSteps = 10000
+	recordMaxChunks  = 0
+
+	episodeLogDir = "" // episode logging disabled
+
+	mapFile = "" // maps are generated
+	mapDir  = ""
+
+	generator     = string(game.DefaultMapGenerator)
+	rooms         = game.DefaultDungeonGenerator.Rooms
+	roomMinSize   = game.DefaultDungeonGenerator.MinRoomSize
+	roomMaxSize   = game.DefaultDungeonGenerator.MaxRoomSize
+	corridorWidth = game.DefaultDungeonGenerator.CorridorWidth
+
+	chaserPort       = -1
+	chaserSocketPath = "" // defaults to /tmp/wolf3d_ipc_chaser.sock
+
+	ticksPerStep   = int64(1)
+	secondsPerTick = 1.0 / 60
 )
 
 func main() {
 	flag.BoolVar(&fullscreen, "f", fullscreen, "fullscreen")
-	flag.IntVar(&width, "w", width, "width")
-	flag.IntVar(&height, "h", height, "height")
-	flag.Float64Var(&scale, "s", scale, "scale")
-	flag.IntVar(&port, "p", port, "port")
+	flag.IntVar(&width, "w", width, "width of the views and observations")
+	flag.IntVar(&height, "h", height, "height of the views and observations")
+	flag.Float64Var(&scale, "s", scale, "scale of the window over the views")
+	flag.IntVar(&port, "p", port, "tcp port to listen on next to the unix socket, 0 picks a random port, -1 disables tcp")
+	flag.StringVar(&host, "host", host, "interface the tcp listener binds to")
+	flag.StringVar(&socketPath, "socket", socketPath, "unix socket path, a leading @ names a linux abstract socket (default /tmp/wolf3d_ipc_player.sock)")
+	flag.BoolVar(&headless, "headless", headless, "run without a window, observations are only served over ipc")
+	flag.Int64Var(&seed, "seed", seed, "seed for map generation and spawns, 0 picks a random seed")
+	flag.Int64Var(&ticksPerStep, "ticks-per-step", ticksPerStep, "simulated ticks that pass for every action")
+	flag.Float64Var(&secondsPerTick, "seconds-per-tick", secondsPerTick, "simulated seconds per tick, episode timeouts are measured in these")
+	flag.IntVar(&envs, "envs", envs, "number of independent environments hosted by this process")
+	flag.StringVar(&chaser, "chaser", chaser, "scripted chaser behaviour: idle, random_walk, patrol, line_of_sight or shortest_path")
+	flag.BoolVar(&autopilot, "autopilot", autopilot, "the autopilot drives the runner in the window, toggle it with P")
+	flag.BoolVar(&lighting, "lighting", lighting, "light walls, floors and ceilings with the map's light sources, walls cast shadows")
+	flag.BoolVar(&shading, "wall-shading", shading, "shade the walls per pixel with the normal map and shift their textures with the displacement map")
+	flag.BoolVar(&doors, "doors", doors, "hang closed doors in the doorways of every map, see the Doors difficulty parameter")
+	flag.StringVar(&recordDir, "record-dir", recordDir, "record every runner step into a chunked trajectory dataset in this directory, one sub directory per env when hosting several")
+	flag.IntVar(&recordChunkSteps, "record-chunk-steps", recordChunkSteps, "steps per recorded chunk, a chunk is closed at the first episode end after this many steps")
+	flag.IntVar(&recordMaxChunks, "record-max-chunks", recordMaxChunks, "delete the oldest recorded chunks beyond this many, 0 keeps every chunk")
+	flag.StringVar(&episodeLogDir, "episode-log-dir", episodeLogDir, "log every episode into this directory for a deterministic replay, one sub directory per env when hosting several")
+	flag.StringVar(&mapFile, "map", mapFile, "play this map file instead of generated maps")
+	flag.StringVar(&mapDir, "map-dir", mapDir, "play the map files in this directory instead of generated maps, one is picked by seed per reset")
+	flag.StringVar(&generator, "generator", generator, "map generator: scatter, dungeon, bsp, maze or caves")
+	flag.IntVar(&rooms, "rooms", rooms, "rooms per dungeon map")
+	flag.IntVar(&roomMinSize, "room-min-size", roomMinSize, "smallest room floor of dungeon maps, in cells")
+	flag.IntVar(&roomMaxSize, "room-max-size", roomMaxSize, "largest room floor of dungeon maps, in cells")
+	flag.IntVar(&corridorWidth, "corridor-width", corridorWidth, "corridor width of dungeon maps, in cells")
+	flag.IntVar(&chaserPort, "chaser-p", chaserPort, "tcp port of the chaser endpoint, 0 picks a random port, -1 disables tcp")
+	flag.StringVar(&chaserSocketPath, "chaser-socket", chaserSocketPath, "unix socket path of the chaser endpoint (default /tmp/wolf3d_ipc_chaser.sock)")
 	flag.Parse()
 
-	g := newGame(width, height, scale, fullscreen)
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	log.Println("Using seed: ", seed)
+
+	if err := game.ChaserBehaviour(chaser).Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	maps, err := loadMaps(mapFile, mapDir)
+	if err != nil {
+		log.Fatal("Error loading maps: ", err)
+	}
+
+	if err := game.MapGeneratorName(generator).Validate(); err != nil {
+		log.Fatal(err)
+	}
+	dungeon := &game.DungeonGenerator{Rooms: rooms, MinRoomSize: roomMinSize, MaxRoomSize: roomMaxSize, CorridorWidth: corridorWidth}
+	if err := dungeon.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	if envs < 1 {
+		log.Fatal("-envs must be at least 1")
+	}
+
+	if maps != nil {
+		log.Println("Playing ", len(maps), " fixed maps")
+	}
+
+	// Every environment is configured before its first episode, so that episode already plays the configured maps,
+	// generator and rendering. Additional environments are always headless, only the first one is shown in the window.
+	games := make([]*game.GameInstance, envs)
+	for i := range games {
+		games[i] = &game.GameInstance{
+			RenderWidth:      width,
+			RenderHeight:     height,
+			RenderScale:      scale,
+			RenderFullscreen: fullscreen,
+			Headless:         headless || i > 0,
+			Seed:             seed + int64(i),
+			TicksPerStep:     ticksPerStep,
+			SecondsPerTick:   secondsPerTick,
+			ChaserBehaviour:  game.ChaserBehaviour(chaser),
+			Maps:             maps,
+			Generator:        game.MapGeneratorName(generator),
+			Dungeon:          dungeon,
+			Lighting:         lighting,
+			WallShading:      shading,
+			Doors:            doors,
+		}
+	}
+	g := games[0]
+	g.UseAutopilot = autopilot
+
+	if recordDir != "" {
+		for i, env := range games {
+			dir := recordDir
+			if len(games) > 1 {
+				dir = filepath.Join(recordDir, fmt.Sprintf("env%d", i))
+			}
+
+			recorder, err := game.NewRecorder(game.RecorderConfig{Dir: dir, StepsPerChunk: recordChunkSteps, MaxChunks: recordMaxChunks})
+			if err != nil {
+				log.Fatal("Error creating recorder: ", err)
+			}
+			env.Recorder = recorder
+			defer recorder.Close()
+		}
+		log.Println("Recording trajectories to ", recordDir)
+	}
+
+	if episodeLogDir != "" {
+		for i, env := range games {
+			env.EpisodeLogDir = episodeLogDir
+			if len(games) > 1 {
+				env.EpisodeLogDir = filepath.Join(episodeLogDir, fmt.Sprintf("env%d", i))
+			}
+		}
+		log.Println("Logging episodes to ", episodeLogDir)
+	}
+
+	// the game in the window starts when the window opens, see GameLoop
+	for _, env := range games {
+		if env.Headless {
+			env.StartHeadless()
+		}
+	}
+
+	tcpHost := ""
+	if port >= 0 {
+		tcpHost = host
+	}
 
 	// Set up the ipc servers used for controlling each player
 	ipcServer := &ipc.IpcServer{
-		Game: g,
+		Games: games,
 		Config: &ipc.ServerConfig{
-			IpcName: "wolf3d_ipc_player",
-			Port:    port,
-			Timeout: 0,
-			//MaxMsgSize:        1024 * 1024 * 10,
+			IpcName:    "wolf3d_ipc_player",
+			SocketPath: socketPath,
+			TcpHost:    tcpHost,
+			Port:       port,
+			Timeout:    0,
+			// raw frames of every environment have to fit into a single vector step reply
+			MaxMsgSize:        4 * 1024 * 1024 * len(games),
 			Encryption:        false,
 			UnmaskPermissions: false,
 		},
 	}
 
+	registerPlayerHandlers(ipcServer)
+
 	sc, err := ipcServer.Start()
 	if err != nil {
 		return
 	}
 
+	// A second agent can drive the chaser of the first environment, it is stepped in lockstep with the runner
+	chaserTcpHost := ""
+	if chaserPort >= 0 {
+		chaserTcpHost = host
+	}
+
+	chaserServer := &ipc.IpcServer{
+		Games: games[:1],
+		Config: &ipc.ServerConfig{
+			IpcName:           "wolf3d_ipc_chaser",
+			SocketPath:        chaserSocketPath,
+			TcpHost:           chaserTcpHost,
+			Port:              chaserPort,
+			Timeout:           0,
+			MaxMsgSize:        4 * 1024 * 1024,
+			Encryption:        false,
+			UnmaskPermissions: false,
+		},
+	}
+
+	registerChaserHandlers(chaserServer)
+
+	cs, err := chaserServer.Start()
+	if err != nil {
+		return
+	}
+
+	go playerMessageLoop(cs)
+
+	if headless {
+		playerMessageLoop(sc)
+		return
+	}
+
 	go playerMessageLoop(sc)
 
-	pixelgl.Run(g.GameLoop)
+	game.RunWindow(g.GameLoop)
 }
 
 func playerMessageLoop(sc *ipc.IpcServer) {
@@ -56,7 +244,7 @@ func playerMessageLoop(sc *ipc.IpcServer) {
 
 		if err == nil {
 
-			handleServerPlayerMessage(sc, m)
+			sc.Dispatch(m)
 
 		} else {
 			log.Println("IpcConnection error")
@@ -66,52 +254,19 @@ func playerMessageLoop(sc *ipc.IpcServer) {
 	}
 }
 
-func handleServerPlayerMessage(sc *ipc.IpcServer, m *ipc.Message) {
-	if m.MsgType == 11 && string(m.Data) == "ping" {
-		sc.Connection.Write(12, []byte("pong"))
-	} else if m.MsgType == 13 && string(m.Data) == "reset" {
-		sc.Game.Reset()
-		sc.Connection.Write(14, []byte("reset ok"))
-	} else if m.MsgType == 16 && string(m.Data) == "begin control" {
-		sc.Connection.Write(17, []byte("control granted"))
-	} else if m.MsgType == 18 && string(m.Data) == "get observation" {
-
-		p1, p2 := sc.Game.GetPlayer1Observation()
-		result := game.RLActionResult{Reward: 0.0, Done: false, Info: "dummy", Observation: p2, Observation_Pos: p1}
-		resultJson := result.ToJson()
-
-		err := sc.Connection.Write(19, []byte(*resultJson))
-
+// loadMaps loads a single map file or a directory of them, nil when maps are generated
+func loadMaps(mapFile string, mapDir string) ([]*game.Map, error) {
+	if mapFile != "" {
+		m, err := game.LoadMap(mapFile)
 		if err != nil {
-			fmt.Println("Error writing observation: ", err)
+			return nil, err
 		}
-	} else if m.MsgType == 20 {
-		result := sc.Game.TakePlayer1Action(game.RLAction(m.Data[0]))
-		resultJson := result.ToJson()
-
-		if resultJson != nil {
-			writeError := sc.Connection.Write(21, []byte(*resultJson))
-			if writeError != nil {
-				fmt.Println("Error writing result message: ", writeError)
-			}
-		} else {
-			fmt.Println("Error converting action result to json")
-		}
-
-		return
-	} else if m.MsgType == -1 {
-		// Control messages
-		return
-	} else {
-		log.Fatal("Unknown message type: ", m.MsgType)
+		return []*game.Map{m}, nil
 	}
-}
 
-func newGame(width int, height int, scale float64, fullscreen bool) *game.GameInstance {
-	return &game.GameInstance{
-		RenderWidth:      width,
-		RenderHeight:     height,
-		RenderScale:      scale,
-		RenderFullscreen: fullscreen,
+	if mapDir != "" {
+		return game.LoadMapDir(mapDir)
 	}
+
+	return nil, nil
 }